@@ -0,0 +1,768 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shapestone/cc-foundry/pkg/doctor"
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/installer"
+	"github.com/shapestone/cc-foundry/pkg/lint"
+)
+
+// Exit codes for non-interactive commands
+const (
+	exitOK        = 0 // command succeeded
+	exitError     = 1 // command failed
+	exitUsage     = 2 // invalid arguments or flags
+	exitCancelled = 3 // user declined the confirmation prompt
+	exitUnhealthy = 4 // doctor found errors (or warnings with --strict)
+)
+
+// catalogCommands are the subcommands that read the catalog or the state
+// version and help print without either, and sources and update-catalog read only the config file
+var catalogCommands = map[string]bool{
+	"install":  true,
+	"remove":   true,
+	"doctor":   true,
+	"sync":     true,
+	"adopt":    true,
+	"diff":     true,
+	"outdated": true,
+	"lint":     true,
+	"profiles": true,
+}
+
+// runCommand dispatches a non-interactive subcommand and returns its exit code
+func runCommand(name string, args []string) int {
+	// Subcommands never clear the screen or draw the banner
+	installer.Headless = true
+
+	switch name {
+	case "install":
+		return runInstall(args)
+	case "remove":
+		return runRemove(args)
+	case "doctor":
+		return runDoctor(args)
+	case "sync":
+		return runSync(args)
+	case "adopt":
+		return runAdopt(args)
+	case "diff":
+		return runDiff(args)
+	case "outdated":
+		return runOutdated(args)
+	case "sources":
+		return runSources(args)
+	case "update-catalog":
+		return runUpdateCatalog(args)
+	case "lint":
+		return runLint(args)
+	case "profiles":
+		return runProfiles(args)
+	case "version", "--version", "-v":
+		showVersion()
+		return exitOK
+	case "help", "--help", "-h":
+		printUsage()
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown command '%s'\n", name)
+		fmt.Fprintln(os.Stderr, "Run 'cc-foundry help' for usage.")
+		return exitUsage
+	}
+}
+
+// runInstall implements: cc-foundry install <selector> [--scope user|project] [--force] [--yes]
+func runInstall(args []string) int {
+	fs := flag.NewFlagSet("install", flag.ContinueOnError)
+	scope := fs.String("scope", "user", "install location: user (~/.claude/) or project (.claude/)")
+	onModified := fs.String("on-modified", "keep", "for installed files with local edits: keep, overwrite, or merge")
+	force := fs.Bool("force", false, "allow replacing installed files with an older catalog version")
+	yes := fs.Bool("yes", false, "skip the confirmation prompt")
+	fs.BoolVar(yes, "y", false, "shorthand for --yes")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cc-foundry install <category>[/<type>[/<file>]] [--scope user|project] [--on-modified keep|overwrite|merge] [--force] [--yes]")
+		fmt.Fprintln(fs.Output(), "       cc-foundry install profile:<name> [--scope user|project] [--on-modified keep|overwrite|merge] [--force] [--yes]")
+		fmt.Fprintln(fs.Output(), "       cc-foundry install all [--scope user|project] [--on-modified keep|overwrite|merge] [--force] [--yes]")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	sel, err := installer.ParseSelector(positional[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	if err := applyScope(*scope); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	strategy, err := installer.ParseUpdateStrategy(*onModified)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --on-modified: %v\n", err)
+		return exitUsage
+	}
+	installer.CurrentUpdateStrategy = strategy
+	installer.AllowDowngrade = *force
+
+	if !*yes {
+		if err := installer.PrintInstallPreview(sel); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+		if !confirm(os.Stdin, "Proceed with installation?") {
+			fmt.Println("Installation cancelled.")
+			return exitCancelled
+		}
+	}
+
+	if err := installer.InstallSelection(sel); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// runRemove implements: cc-foundry remove <selector> [--scope user|project] [--dry-run] [--yes]
+func runRemove(args []string) int {
+	fs := flag.NewFlagSet("remove", flag.ContinueOnError)
+	scope := fs.String("scope", "user", "location to remove from: user (~/.claude/) or project (.claude/)")
+	dryRun := fs.Bool("dry-run", false, "show what would be removed without removing anything")
+	yes := fs.Bool("yes", false, "skip the confirmation prompt")
+	fs.BoolVar(yes, "y", false, "shorthand for --yes")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cc-foundry remove <category>[/<type>[/<file>]] [--scope user|project] [--dry-run] [--yes]")
+		fmt.Fprintln(fs.Output(), "       cc-foundry remove profile:<name> [--scope user|project] [--dry-run] [--yes]")
+		fmt.Fprintln(fs.Output(), "       cc-foundry remove all [--scope user|project] [--dry-run] [--yes]")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	sel, err := installer.ParseSelector(positional[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	if err := applyScope(*scope); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	installations, err := installer.ListSelectedInstallations(sel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if len(installations) == 0 {
+		fmt.Printf("No files installed from %s [%s]\n", sel, installer.GetInstallModeDescription())
+		return exitOK
+	}
+
+	if *dryRun || !*yes {
+		if err := installer.PrintRemovePreview(sel); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+		if *dryRun {
+			return exitOK
+		}
+		if !confirm(os.Stdin, "Proceed with removal?") {
+			fmt.Println("Removal cancelled.")
+			return exitCancelled
+		}
+	}
+
+	if err := installer.RemoveSelection(sel); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// runDoctor implements: cc-foundry doctor [--json] [--fix] [--strict]
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	jsonOutput := fs.Bool("json", false, "print the health report as JSON")
+	fix := fs.Bool("fix", false, "apply every available fix without prompting")
+	strict := fs.Bool("strict", false, "also exit non-zero when warnings are found")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cc-foundry doctor [--json] [--fix] [--strict]")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	doctor.Quiet = *jsonOutput
+
+	report, err := doctor.Run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running doctor: %v\n", err)
+		return exitError
+	}
+
+	if *fix {
+		fixes := doctor.ApplyFixes(report)
+		if len(fixes) > 0 {
+			// Re-check so the report and exit status reflect the repaired state
+			doctor.Quiet = true
+			report, err = doctor.Run()
+			doctor.Quiet = *jsonOutput
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error running doctor: %v\n", err)
+				return exitError
+			}
+			report.Fixes = fixes
+			if !*jsonOutput {
+				fmt.Println()
+			}
+		}
+	}
+
+	if *jsonOutput {
+		if err := doctor.WriteJSON(os.Stdout, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			return exitError
+		}
+	} else {
+		doctor.PrintReport(report)
+	}
+
+	if report.Errors > 0 || (*strict && report.Warnings > 0) {
+		return exitUnhealthy
+	}
+	return exitOK
+}
+
+// runSync implements: cc-foundry sync
+func runSync(args []string) int {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cc-foundry sync")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Installs exactly the files pinned in .claude/cc-foundry.lock into the current project.")
+	}
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	if err := installer.SyncLockfile(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// runAdopt implements: cc-foundry adopt [<path>...] [--scope user|project] [--dry-run] [--yes]
+func runAdopt(args []string) int {
+	fs := flag.NewFlagSet("adopt", flag.ContinueOnError)
+	scope := fs.String("scope", "user", "location to adopt files in: user (~/.claude/) or project (.claude/)")
+	dryRun := fs.Bool("dry-run", false, "show what would be adopted without adopting anything")
+	yes := fs.Bool("yes", false, "skip the confirmation prompt")
+	fs.BoolVar(yes, "y", false, "shorthand for --yes")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cc-foundry adopt [<path>...] [--scope user|project] [--dry-run] [--yes]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Registers files copied into .claude/ by hand as installed from the catalog file they match,")
+		fmt.Fprintln(fs.Output(), "without rewriting them. Without paths, every unmanaged foundry file that matches is adopted.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+
+	if err := applyScope(*scope); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	var adoptions []installer.Adoption
+	if len(positional) == 0 {
+		adoptions, err = installer.FindAdoptable()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+	} else {
+		claudeDir, err := installer.GetClaudeCodeDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+		for _, path := range positional {
+			adoption, err := installer.MatchUnmanaged(claudeDir, path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return exitError
+			}
+			adoptions = append(adoptions, *adoption)
+		}
+	}
+	if len(adoptions) == 0 {
+		fmt.Printf("No unmanaged files to adopt [%s]\n", installer.GetInstallModeDescription())
+		return exitOK
+	}
+
+	if *dryRun || !*yes {
+		installer.PrintAdoptPreview(adoptions)
+		if *dryRun {
+			return exitOK
+		}
+		if !confirm(os.Stdin, "Proceed with adoption?") {
+			fmt.Println("Adoption cancelled.")
+			return exitCancelled
+		}
+	}
+
+	paths := make([]string, len(adoptions))
+	for i, adoption := range adoptions {
+		paths[i] = adoption.Path
+	}
+	if _, err := installer.Adopt(paths); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	fmt.Printf("✓ Adopted %d files [%s]\n", len(adoptions), installer.GetInstallModeDescription())
+	return exitOK
+}
+
+// runDiff implements: cc-foundry diff [<selector>] [--scope user|project] [--local]
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	scope := fs.String("scope", "user", "location to compare: user (~/.claude/) or project (.claude/)")
+	local := fs.Bool("local", false, "show edits made to installed files instead of pending catalog updates")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cc-foundry diff [<category>[/<type>[/<file>]] | all] [--scope user|project] [--local]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Shows what updating installed files from the catalog would change, or with --local,")
+		fmt.Fprintln(fs.Output(), "what was edited in the installed files since they were installed.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(positional) > 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	selector := "all"
+	if len(positional) == 1 {
+		selector = positional[0]
+	}
+	sel, err := installer.ParseSelector(selector)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	if err := applyScope(*scope); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	diffs, err := installer.SelectionDiffs(sel, *local)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if diffs == "" {
+		fmt.Printf("No differences for %s [%s]\n", sel, installer.GetInstallModeDescription())
+		return exitOK
+	}
+
+	fmt.Print(diffs)
+	return exitOK
+}
+
+// runOutdated implements: cc-foundry outdated [--scope user|project]
+func runOutdated(args []string) int {
+	fs := flag.NewFlagSet("outdated", flag.ContinueOnError)
+	scope := fs.String("scope", "user", "location to check: user (~/.claude/) or project (.claude/)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cc-foundry outdated [--scope user|project]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Lists installed files whose catalog version is newer than the installed one.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(positional) > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	if err := applyScope(*scope); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	outdated, err := installer.ListOutdated()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if len(outdated) == 0 {
+		fmt.Printf("All installed files are up to date [%s]\n", installer.GetInstallModeDescription())
+		return exitOK
+	}
+
+	fmt.Printf("Outdated files [%s]:\n", installer.GetInstallModeDescription())
+	for _, file := range outdated {
+		installed := embedpkg.VersionLabel(file.Installed)
+		if installed == "" {
+			installed = "unknown"
+		}
+		selector := fmt.Sprintf("%s/%s/%s", file.Installation.Category, file.Installation.Type, strings.TrimSuffix(file.Installation.File, ".md"))
+		fmt.Printf("  %-50s %s → %s\n", selector, installed, embedpkg.VersionLabel(file.Latest))
+	}
+	fmt.Println()
+	fmt.Printf("Update with: cc-foundry install <selector> --scope %s\n", *scope)
+	return exitOK
+}
+
+// runProfiles implements: cc-foundry profiles [--scope user|project]
+func runProfiles(args []string) int {
+	fs := flag.NewFlagSet("profiles", flag.ContinueOnError)
+	scope := fs.String("scope", "user", "location whose installed profile files are counted: user (~/.claude/) or project (.claude/)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cc-foundry profiles [--scope user|project]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Lists the profiles of the catalog and of ~/.cc-foundry/config.json with their files.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(positional) > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	if err := applyScope(*scope); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	profiles, err := embedpkg.ListProfiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if len(profiles) == 0 {
+		fmt.Println("No profiles defined")
+		return exitOK
+	}
+
+	st, err := installer.LoadState(installer.CurrentInstallMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	for _, profile := range profiles {
+		installed := len(st.ListProfileInstallations(profile.Name))
+		fmt.Printf("  %-20s %-16s %d files, %d installed by this profile\n", profile.Name, profile.Source, len(profile.Files), installed)
+		if profile.Description != "" {
+			fmt.Printf("      %s\n", profile.Description)
+		}
+		for _, ref := range profile.Files {
+			fmt.Printf("      - %s\n", ref)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("Install with: cc-foundry install %s<name> --scope %s\n", installer.ProfileSelectorPrefix, *scope)
+	return exitOK
+}
+
+// runSources implements: cc-foundry sources [update]
+func runSources(args []string) int {
+	fs := flag.NewFlagSet("sources", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cc-foundry sources [update]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Lists the catalog sources configured in ~/.cc-foundry/config.json.")
+		fmt.Fprintln(fs.Output(), "'update' fetches the latest version of git and HTTP sources.")
+	}
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(positional) > 1 || (len(positional) == 1 && positional[0] != "update") {
+		fs.Usage()
+		return exitUsage
+	}
+
+	config, err := embedpkg.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	status := exitOK
+	if len(positional) == 1 {
+		for _, sc := range config.Sources {
+			if !sc.IsFetched() {
+				fmt.Printf("· %s is read in place, nothing to update\n", sc.Name)
+				continue
+			}
+			if err := embedpkg.UpdateSource(sc); err != nil {
+				fmt.Fprintf(os.Stderr, "✗ %s: %v\n", sc.Name, err)
+				status = exitError
+				continue
+			}
+			fmt.Printf("✓ %s updated\n", sc.Name)
+		}
+		return status
+	}
+
+	fmt.Printf("  %-16s %-8s %s\n", "builtin", "embedded", "(compiled into this binary)")
+	for _, source := range embedpkg.OpenSources(config.Sources) {
+		location := source.Config.Location()
+		if source.Config.AllowUnsigned {
+			location += " (signature not checked)"
+		}
+		fmt.Printf("  %-16s %-8s %s\n", source.Config.Name, source.Config.Type, location)
+		if source.Err != nil {
+			fmt.Printf("      ✗ %v\n", source.Err)
+			status = exitError
+		}
+	}
+	return status
+}
+
+// runUpdateCatalog implements: cc-foundry update-catalog [<source>...]
+func runUpdateCatalog(args []string) int {
+	fs := flag.NewFlagSet("update-catalog", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cc-foundry update-catalog [<source>...]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Downloads manifest.json and its bundle for every manifest source with an http(s) url")
+		fmt.Fprintln(fs.Output(), "(or only the named sources) into ~/.cache/cc-foundry/<source>/.")
+		fmt.Fprintln(fs.Output(), "When a download fails, the last good cached catalog stays in use.")
+	}
+
+	names, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+
+	config, err := embedpkg.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	status := exitOK
+	fetched := 0
+	for _, sc := range config.Sources {
+		if len(wanted) > 0 && !wanted[sc.Name] {
+			continue
+		}
+		delete(wanted, sc.Name)
+		if !sc.IsRemote() {
+			if len(names) > 0 {
+				fmt.Printf("· %s is not an HTTP catalog, nothing to download\n", sc.Name)
+			}
+			continue
+		}
+		fetched++
+
+		result, err := embedpkg.FetchCatalog(sc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", sc.Name, err)
+			if cached, ok := embedpkg.CachedAt(sc); ok {
+				fmt.Fprintf(os.Stderr, "  Using the cached catalog from %s\n", cached.Local().Format("2006-01-02 15:04"))
+			} else {
+				fmt.Fprintln(os.Stderr, "  No cached copy; using the built-in catalog")
+			}
+			status = exitError
+			continue
+		}
+
+		if result.Changed {
+			fmt.Printf("✓ %s: downloaded catalog version %s\n", sc.Name, result.Version)
+		} else {
+			fmt.Printf("✓ %s: up to date (version %s)\n", sc.Name, result.Version)
+		}
+	}
+
+	for name := range wanted {
+		fmt.Fprintf(os.Stderr, "Error: no source named '%s' in ~/.cc-foundry/config.json\n", name)
+		status = exitUsage
+	}
+	if fetched == 0 && len(names) == 0 {
+		fmt.Println("No HTTP catalog sources configured (see 'cc-foundry help', Catalog Sources)")
+	}
+	return status
+}
+
+// runLint implements: cc-foundry lint [path] [--format text|github|json] [--strict]
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text (file:line: severity: message), github (Actions annotations), or json")
+	strict := fs.Bool("strict", false, "also exit non-zero when warnings are found")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cc-foundry lint [path] [--format text|github|json] [--strict]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Checks catalog files: frontmatter, agent tools, unique names, size, and relative links.")
+		fmt.Fprintln(fs.Output(), "path holds categories/<category>/<type>/*.md or <category>/<type>/*.md; without it the")
+		fmt.Fprintln(fs.Output(), "active catalog (built-in plus configured sources) is checked.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(positional) > 1 || (*format != "text" && *format != "github" && *format != "json") {
+		fs.Usage()
+		return exitUsage
+	}
+
+	catalog, root := embedpkg.CategoriesFS, ""
+	if len(positional) == 1 {
+		root = filepath.ToSlash(filepath.Clean(positional[0]))
+		info, err := os.Stat(positional[0])
+		if err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Error: %s is not a directory\n", positional[0])
+			return exitUsage
+		}
+		catalog = os.DirFS(positional[0])
+	}
+
+	report, err := lint.Run(catalog, root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	switch *format {
+	case "github":
+		lint.WriteGitHub(os.Stdout, report)
+	case "json":
+		if err := lint.WriteJSON(os.Stdout, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			return exitError
+		}
+	default:
+		lint.WriteText(os.Stdout, report)
+	}
+
+	if report.Errors > 0 || (*strict && report.Warnings > 0) {
+		return exitError
+	}
+	return exitOK
+}
+
+// parseFlags parses flags that may appear before, between, or after positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// flagExitCode maps a flag parsing error to an exit code (-h/--help is not an error)
+func flagExitCode(err error) int {
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	return exitUsage
+}
+
+// applyScope sets the installer's install mode from a --scope value
+func applyScope(scope string) error {
+	switch scope {
+	case "user":
+		installer.CurrentInstallMode = installer.InstallModeUser
+	case "project":
+		installer.CurrentInstallMode = installer.InstallModeProject
+	default:
+		return fmt.Errorf("invalid scope '%s' (expected user or project)", scope)
+	}
+	return nil
+}
+
+// confirm asks a yes/no question on stdin; anything other than y/yes (including EOF) is "no"
+func confirm(in io.Reader, prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/installer"
+)
+
+// captureOutput runs fn with stdout redirected and stdin reading input, and returns what it printed
+func captureOutput(t *testing.T, input string, fn func()) string {
+	t.Helper()
+
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalStdout, originalStdin := os.Stdout, os.Stdin
+	os.Stdout, os.Stdin = w, stdin
+	defer func() { os.Stdout, os.Stdin = originalStdout, originalStdin }()
+
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+
+	fn()
+	w.Close()
+	return <-done
+}
+
+// TestRunRemove tests the exit codes and output of the remove subcommand
+func TestRunRemove(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	originalMode := installer.CurrentInstallMode
+	originalHeadless := installer.Headless
+	defer func() {
+		installer.CurrentInstallMode = originalMode
+		installer.Headless = originalHeadless
+	}()
+
+	auditor := filepath.Join(home, ".claude", "agents", "ccf-oss-development-oss-auditor.md")
+	mine := filepath.Join(home, ".claude", "agents", "mine.md")
+
+	tests := []struct {
+		name       string
+		args       []string
+		input      string // answer to the confirmation prompt
+		wantCode   int
+		wantOutput string
+		wantExists bool // whether the installed agent is still there afterwards
+	}{
+		{"no selector", []string{}, "", exitUsage, "", true},
+		{"invalid selector", []string{"oss-development/widgets"}, "", exitUsage, "", true},
+		{"invalid scope", []string{"oss-development", "--scope", "global"}, "", exitUsage, "", true},
+		{"nothing matched", []string{"development", "--yes"}, "", exitOK, "No files installed from development [user (~/.claude/)]", true},
+		{"dry run", []string{"oss-development/agents/oss-auditor", "--dry-run"}, "", exitOK, "Summary: 1 files will be removed", true},
+		{"declined", []string{"oss-development/agents"}, "n\n", exitCancelled, "Removal cancelled.", true},
+		{"confirmed", []string{"oss-development/agents"}, "y\n", exitOK, "Successfully removed", false},
+		{"yes", []string{"oss-development", "--yes"}, "", exitOK, "Successfully removed", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureOutput(t, "", func() {
+				if code := runCommand("install", []string{"oss-development/agents/oss-auditor", "--yes"}); code != exitOK {
+					t.Fatalf("install exited %d", code)
+				}
+			})
+			if err := os.WriteFile(mine, []byte("mine\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var code int
+			output := captureOutput(t, tt.input, func() { code = runCommand("remove", tt.args) })
+
+			if code != tt.wantCode {
+				t.Errorf("remove %q exited %d, want %d", tt.args, code, tt.wantCode)
+			}
+			if !strings.Contains(output, tt.wantOutput) {
+				t.Errorf("remove %q printed %q, want it to contain %q", tt.args, output, tt.wantOutput)
+			}
+			if _, err := os.Stat(auditor); (err == nil) != tt.wantExists {
+				t.Errorf("remove %q: installed agent exists = %v, want %v", tt.args, err == nil, tt.wantExists)
+			}
+			if _, err := os.Stat(mine); err != nil {
+				t.Errorf("remove %q touched an unmanaged file: %v", tt.args, err)
+			}
+		})
+	}
+}
+
+// TestProfilesResolve tests that the built-in profiles and the profile example in the usage only list catalog files
+func TestProfilesResolve(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	profiles, err := embedpkg.ListProfiles()
+	if err != nil {
+		t.Fatalf("ListProfiles() error = %v", err)
+	}
+	if len(profiles) == 0 {
+		t.Fatal("ListProfiles() found no built-in profiles")
+	}
+
+	usage := captureOutput(t, "", printUsage)
+	_, example, _ := strings.Cut(usage, "\nProfiles:\n")
+	example, _, _ = strings.Cut(example, "\n\n")
+	var files []string
+	for _, match := range regexp.MustCompile(`"([\w-]+/[\w-]+/[\w-]+)"`).FindAllStringSubmatch(example, -1) {
+		files = append(files, match[1])
+	}
+	if len(files) == 0 {
+		t.Fatalf("no profile files found in the usage:\n%s", example)
+	}
+	profiles = append(profiles, embedpkg.Profile{Name: "usage example", Files: files})
+
+	for _, profile := range profiles {
+		if _, err := profile.Resolve(); err != nil {
+			t.Errorf("Resolve() error = %v", err)
+		}
+	}
+}
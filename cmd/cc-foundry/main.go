@@ -9,6 +9,7 @@ import (
 	"github.com/shapestone/cc-foundry/pkg/doctor"
 	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
 	"github.com/shapestone/cc-foundry/pkg/installer"
+	"github.com/shapestone/cc-foundry/pkg/state"
 )
 
 const version = "2.0.0"
@@ -27,13 +28,62 @@ func init() {
 func main() {
 	// Interactive mode - show main menu
 	if len(os.Args) < 2 {
+		prepareCatalog()
 		runInteractiveMode()
 		return
 	}
 
-	// For future: support command-line arguments for scripting
-	// For now, always run interactive mode
-	runInteractiveMode()
+	// Non-interactive mode - run a subcommand for scripting and CI
+	// Only subcommands that read the catalog or state migrate state files and open sources
+	if catalogCommands[os.Args[1]] {
+		prepareCatalog()
+	}
+	os.Exit(runCommand(os.Args[1], os.Args[2:]))
+}
+
+// prepareCatalog migrates legacy state and loads the configured catalog sources
+func prepareCatalog() {
+	// Split the pre-2.1 global state file into per-directory state files
+	migrateLegacyState()
+
+	// Layer configured catalog sources over the built-in catalog
+	loadCatalogSources()
+}
+
+// migrateLegacyState moves entries from ~/.cc-foundry.json into the state file of each .claude/ directory
+func migrateLegacyState() {
+	migrated, err := state.MigrateLegacy()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: failed to migrate ~/.cc-foundry.json: %v\n", err)
+		return
+	}
+	if migrated > 0 {
+		fmt.Fprintf(os.Stderr, "📦 Migrated %d tracked installation(s) from ~/.cc-foundry.json to per-directory state files\n", migrated)
+	}
+}
+
+// loadCatalogSources makes the configured sources in ~/.cc-foundry/config.json part of the catalog
+// and applies its naming policy
+// A broken source is reported and skipped so the rest of the catalog stays usable
+func loadCatalogSources() {
+	config, err := embedpkg.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: %v (using the built-in catalog only)\n", err)
+		return
+	}
+	installer.Naming = config.Naming
+	embedpkg.SeparateSources = config.Naming.QualifySource
+
+	sources := embedpkg.OpenSources(config.Sources)
+	for _, source := range sources {
+		if source.Err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Warning: catalog source '%s' skipped: %v\n", source.Config.Name, source.Err)
+		}
+	}
+
+	if err := embedpkg.Use(embeddata.Categories, sources); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: failed to load catalog sources: %v\n", err)
+	}
 }
 
 func runInteractiveMode() {
@@ -65,7 +115,7 @@ func runInteractiveMode() {
 			printUsage()
 			installer.WaitForKey()
 		case installer.MainMenuExit:
-			fmt.Println("\nGoodbye! 👋\n")
+			fmt.Print("\nGoodbye! 👋\n\n")
 			return
 		}
 	}
@@ -91,8 +141,10 @@ func handleListInteractive() {
 		return
 	}
 
-	// List all or specific category
-	if category == "all" {
+	// List all, a profile, or a specific category
+	if name, ok := strings.CutPrefix(category, installer.ProfileSelectorPrefix); ok {
+		listProfile(name)
+	} else if category == "all" {
 		listAll()
 	} else {
 		listCategory(category)
@@ -115,6 +167,18 @@ func handleInstallInteractive() {
 		return
 	}
 
+	// Pick individual files instead of a whole category
+	if category == "files" {
+		handleInstallFilesInteractive()
+		return
+	}
+
+	// Install a profile spanning categories
+	if name, ok := strings.CutPrefix(category, installer.ProfileSelectorPrefix); ok {
+		handleInstallProfileInteractive(name)
+		return
+	}
+
 	// Prompt for location
 	if !installer.PromptForLocation() {
 		return
@@ -161,6 +225,18 @@ func handleRemoveInteractive() {
 		return
 	}
 
+	// Pick individual files instead of a whole category
+	if category == "files" {
+		handleRemoveFilesInteractive()
+		return
+	}
+
+	// Remove the files a profile brought in
+	if name, ok := strings.CutPrefix(category, installer.ProfileSelectorPrefix); ok {
+		handleRemoveProfileInteractive(name)
+		return
+	}
+
 	// Intelligently prompt for location (or auto-select if only one has files)
 	// For "all" categories, pass empty string to check all categories
 	categoryForCheck := category
@@ -198,6 +274,132 @@ func handleRemoveInteractive() {
 	installer.WaitForKey()
 }
 
+// handleInstallFilesInteractive installs individually picked files
+func handleInstallFilesInteractive() {
+	files, err := installer.SelectCatalogFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	// User cancelled or picked nothing
+	if len(files) == 0 {
+		return
+	}
+
+	if !installer.PromptForLocation() {
+		return
+	}
+
+	proceed, err := installer.PreviewInstallFiles(files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		installer.WaitForKey()
+		return
+	}
+
+	if !proceed {
+		fmt.Println("Installation cancelled.")
+		return
+	}
+
+	if err := installer.InstallFiles(files); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		installer.WaitForKey()
+		return
+	}
+	installer.WaitForKey()
+}
+
+// handleInstallProfileInteractive installs every file of a profile
+func handleInstallProfileInteractive(name string) {
+	if !installer.PromptForLocation() {
+		return
+	}
+
+	proceed, err := installer.PreviewInstallProfile(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		installer.WaitForKey()
+		return
+	}
+
+	if !proceed {
+		fmt.Println("Installation cancelled.")
+		return
+	}
+
+	if err := installer.InstallProfile(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		installer.WaitForKey()
+		return
+	}
+	installer.WaitForKey()
+}
+
+// handleRemoveProfileInteractive removes the files a profile brought in
+func handleRemoveProfileInteractive(name string) {
+	if !installer.PromptForLocationForProfileRemoval(name) {
+		return
+	}
+
+	proceed, err := installer.PreviewRemoveProfile(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		installer.WaitForKey()
+		return
+	}
+
+	if !proceed {
+		fmt.Println("Removal cancelled.")
+		return
+	}
+
+	if err := installer.RemoveProfile(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		installer.WaitForKey()
+		return
+	}
+	installer.WaitForKey()
+}
+
+// handleRemoveFilesInteractive removes individually picked files
+func handleRemoveFilesInteractive() {
+	if !installer.PromptForLocationForRemoval("", "") {
+		return
+	}
+
+	installations, err := installer.SelectInstalledFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	// User cancelled or picked nothing
+	if len(installations) == 0 {
+		return
+	}
+
+	proceed, err := installer.PreviewRemoveInstallations(installations)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		installer.WaitForKey()
+		return
+	}
+
+	if !proceed {
+		fmt.Println("Removal cancelled.")
+		return
+	}
+
+	if err := installer.RemoveFiles(installations); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		installer.WaitForKey()
+		return
+	}
+	installer.WaitForKey()
+}
+
 // handleDoctor runs the doctor diagnostics
 func handleDoctor() {
 	installer.ShowBanner()
@@ -211,8 +413,24 @@ func handleDoctor() {
 
 	doctor.PrintReport(report)
 
+	// Offer to show what changed in modified files
+	if report.ModifiedFiles > 0 {
+		diffs, err := installer.ModifiedFilesDiff()
+		if err == nil && diffs != "" {
+			options := []string{"Yes, show changes", "No, continue"}
+			if selected, err := installer.SelectOption("View the changes to modified files?", options); err == nil && selected == 0 {
+				installer.ShowDiff("Local changes to installed files", diffs)
+			}
+		}
+	}
+
 	// Offer to fix issues
-	if err := doctor.OfferFixes(report, installer.SelectOption); err != nil {
+	menus := doctor.Menus{
+		Checklist: installer.SelectGrouped,
+		Select:    installer.SelectOption,
+		Confirm:   installer.SelectOptionInline,
+	}
+	if err := doctor.OfferFixes(report, menus); err != nil {
 		fmt.Fprintf(os.Stderr, "Error offering fixes: %v\n", err)
 		installer.WaitForKey()
 		return
@@ -232,7 +450,7 @@ func showVersion() {
 
 func printUsage() {
 	installer.ShowBanner()
-	fmt.Println(`Interactive Mode:
+	fmt.Print(`Interactive Mode:
   Just run: cc-foundry
 
   The tool will guide you through an interactive menu to:
@@ -252,6 +470,7 @@ User (~/.claude/):
 
 Project (.claude/):
   - Specific to current project, can be version-controlled
+  - Installs are pinned in .claude/cc-foundry.lock; teammates run 'cc-foundry sync'
   .claude/commands/             Command files (flat .md files)
   .claude/agents/               Agent files (flat .md files)
   .claude/skills/[name]/        Skill subdirectories with SKILL.md
@@ -259,8 +478,70 @@ Project (.claude/):
 File Naming:
   Commands/Agents: ccf-[category]-[filename].md
   Skills: ccf-[category]-[name]/SKILL.md
+  The prefix and separator are set by "naming" in ~/.cc-foundry/config.json
+
+Commands (non-interactive, for scripting and CI):
+  cc-foundry install <selector> [--scope user|project] [--on-modified keep|overwrite|merge] [--force] [--yes]
+  cc-foundry remove <selector> [--scope user|project] [--dry-run] [--yes]
+  cc-foundry doctor [--json] [--fix] [--strict]
+  cc-foundry sync
+  cc-foundry adopt [<path>...] [--scope user|project] [--dry-run] [--yes]
+  cc-foundry diff [<selector>] [--scope user|project] [--local]
+  cc-foundry outdated [--scope user|project]
+  cc-foundry sources [update]
+  cc-foundry update-catalog [<source>...]
+  cc-foundry lint [<path>] [--format text|github|json] [--strict]
+  cc-foundry profiles [--scope user|project]
+  cc-foundry version
+  cc-foundry help
+
+  Selectors: all, <category>, <category>/<type>, <category>/<type>/<file>, profile:<name>
+  Example:   cc-foundry install development/skills/project-layout-go --scope project --yes
+
+  Updates never silently overwrite an installed file you edited. --on-modified
+  keeps your version (default), overwrites it, or three-way merges the update in.
+  An install never replaces a file with an older catalog version (by frontmatter
+  version) unless --force is given, and exits 1 when it skipped one; 'outdated'
+  lists files with a newer version.
+
+  adopt registers files copied into .claude/ by hand as installed from the catalog
+  file they match by name or content, without rewriting them; a copy that differs
+  from the catalog is then reported as locally modified.
+
+  lint checks a catalog directory (default: the built-in catalog) for invalid or
+  missing frontmatter, unknown agent tools, duplicate names, oversized files, and
+  broken relative links. It exits 1 on errors, or on warnings with --strict.
+
+Profiles:
+  A profile is a named set of files across categories, installed and removed as
+  one unit. Catalogs define them in categories/<category>/profiles/<name>.md with
+  a "files:" list in the frontmatter; your own go in ~/.cc-foundry/config.json:
+  {"profiles": [{"name": "go-backend", "description": "Go services",
+                 "files": ["development/skills/hexagonal-architecture-go-skill",
+                           "oss-development/agents/oss-auditor"]}]}
+  Removing a profile removes only the files it brought in and no other profile uses.
+
+Catalog Sources:
+  Extra catalogs are configured in ~/.cc-foundry/config.json and layered over the
+  built-in catalog. Each source holds categories/<category>/<type>/*.md:
+  {"sources": [{"name": "pub", "type": "manifest", "path": "/shared/manifest.json",
+                "public_keys": ["<base64 ed25519 key>"]},
+               {"name": "team", "type": "dir", "path": "~/src/skills", "allow_unsigned": true}]}
+  Types: dir, tarball (.tar.gz), git (cloned into ~/.cc-foundry/cache/git/),
+         manifest (manifest.json and bundle.tar.gz from generate-manifest, hash-checked)
+  A manifest source with an http(s) url is downloaded by 'update-catalog' into
+  ~/.cache/cc-foundry/<source>/ and read from there; if a download fails, the last
+  good copy (or, without one, the built-in catalog) stays in use.
+  Manifest and tarball sources must be signed by one of their public_keys; unsigned
+  sources (including every dir and git source) are refused unless "allow_unsigned" is set.
+
+Exit Codes:
+  0  Success
+  1  Command failed
+  2  Invalid arguments
+  3  Cancelled at the confirmation prompt
+  4  Doctor found errors (or warnings with --strict)
 
-Note: Non-interactive mode for scripting will be added in a future release.
 `)
 }
 
@@ -271,7 +552,7 @@ func listAll() {
 		os.Exit(1)
 	}
 
-	fmt.Println("\nAvailable Categories:\n")
+	fmt.Print("\nAvailable Categories:\n\n")
 
 	for _, category := range categories {
 		fmt.Printf("📁 %s/\n", category)
@@ -283,9 +564,9 @@ func listAll() {
 		}
 
 		// Group by type
-		byType := make(map[string][]string)
+		byType := make(map[string][]embedpkg.CategoryFile)
 		for _, file := range files {
-			byType[file.Type] = append(byType[file.Type], file.Filename)
+			byType[file.Type] = append(byType[file.Type], file)
 		}
 
 		// Display by type
@@ -293,8 +574,8 @@ func listAll() {
 			if files, ok := byType[fileType]; ok {
 				typeLabel := strings.Title(fileType)
 				fmt.Printf("  %s:\n", typeLabel)
-				for _, filename := range files {
-					fmt.Printf("    - %s\n", filename)
+				for _, file := range files {
+					printFileEntry("    ", file)
 				}
 			}
 		}
@@ -319,9 +600,9 @@ func listCategory(category string) {
 	fmt.Printf("Category: %s\n\n", category)
 
 	// Group by type
-	byType := make(map[string][]string)
+	byType := make(map[string][]embedpkg.CategoryFile)
 	for _, file := range files {
-		byType[file.Type] = append(byType[file.Type], file.Filename)
+		byType[file.Type] = append(byType[file.Type], file)
 	}
 
 	// Display by type
@@ -329,11 +610,53 @@ func listCategory(category string) {
 		if files, ok := byType[fileType]; ok {
 			typeLabel := strings.Title(fileType)
 			fmt.Printf("%s:\n", typeLabel)
-			for _, filename := range files {
-				fmt.Printf("  - %s\n", filename)
+			for _, file := range files {
+				printFileEntry("  ", file)
 			}
 			fmt.Println()
 		}
 	}
 }
 
+// listProfile prints a profile's description and files
+func listProfile(name string) {
+	installer.ShowBanner()
+
+	profile, err := embedpkg.GetProfile(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Profile: %s\n", profile.Name)
+	if profile.Description != "" {
+		fmt.Printf("  %s\n", profile.Description)
+	}
+	fmt.Println()
+
+	files, err := profile.Resolve()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	for _, file := range files {
+		printFileEntry("  ", embedpkg.CategoryFile{
+			Filename: embedpkg.Reference(file.Category, file.Type, file.Filename),
+			Metadata: file.Metadata,
+		})
+	}
+	fmt.Println()
+}
+
+// printFileEntry prints a catalog file with its version and, on the next line, its description
+func printFileEntry(indent string, file embedpkg.CategoryFile) {
+	if version := file.Metadata.VersionLabel(); version != "" {
+		fmt.Printf("%s- %s (%s)\n", indent, file.Filename, version)
+	} else {
+		fmt.Printf("%s- %s\n", indent, file.Filename)
+	}
+	if file.Metadata.Description != "" {
+		fmt.Printf("%s  %s\n", indent, file.Metadata.ShortDescription(76))
+	}
+}
+
@@ -0,0 +1,117 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name          string
+		original      string
+		local         string
+		catalog       string
+		want          string
+		wantConflicts int
+	}{
+		{
+			name:     "only catalog changed",
+			original: "a\nb\nc\n",
+			local:    "a\nb\nc\n",
+			catalog:  "a\nB\nc\n",
+			want:     "a\nB\nc\n",
+		},
+		{
+			name:     "only local changed",
+			original: "a\nb\nc\n",
+			local:    "a\nb\nc\nmine\n",
+			catalog:  "a\nb\nc\n",
+			want:     "a\nb\nc\nmine\n",
+		},
+		{
+			name:     "disjoint changes",
+			original: "title\n\none\ntwo\nthree\n\nfooter\n",
+			local:    "title\n\none\ntwo\nthree\n\nmy footer\n",
+			catalog:  "new title\n\none\ntwo\nthree\n\nfooter\n",
+			want:     "new title\n\none\ntwo\nthree\n\nmy footer\n",
+		},
+		{
+			name:     "same change on both sides",
+			original: "a\nb\n",
+			local:    "a\nx\n",
+			catalog:  "a\nx\n",
+			want:     "a\nx\n",
+		},
+		{
+			name:          "conflicting changes",
+			original:      "a\nb\nc\n",
+			local:         "a\nmine\nc\n",
+			catalog:       "a\ntheirs\nc\n",
+			want:          "a\n" + MarkerLocal + "\nmine\n" + MarkerOriginal + "\nb\n" + MarkerSplit + "\ntheirs\n" + MarkerCatalog + "\nc\n",
+			wantConflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := Merge3([]byte(tt.original), []byte(tt.local), []byte(tt.catalog))
+			if string(got) != tt.want {
+				t.Errorf("Merge3() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if conflicts != tt.wantConflicts {
+				t.Errorf("Merge3() conflicts = %d, want %d", conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"", nil},
+		{"a\nb\n", []string{"a\n", "b\n"}},
+		{"a\nb", []string{"a\n", "b"}},
+	}
+
+	for _, tt := range tests {
+		got := SplitLines([]byte(tt.content))
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("SplitLines(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestUnified(t *testing.T) {
+	from := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	to := "one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n"
+
+	want := `--- a
++++ b
+@@ -1,5 +1,5 @@
+ one
+-two
++2
+ three
+ four
+ five
+@@ -8,3 +8,4 @@
+ eight
+ nine
+ ten
++eleven
+`
+	if got := Unified("a", "b", []byte(from), []byte(to), DefaultContext); got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := Unified("a", "b", []byte(from), []byte(from), DefaultContext); got != "" {
+		t.Errorf("Unified() of identical content = %q, want empty", got)
+	}
+
+	wantNoNewline := "--- a\n+++ b\n@@ -1,1 +1,1 @@\n-x\n\\ No newline at end of file\n+y\n\\ No newline at end of file\n"
+	if got := Unified("a", "b", []byte("x"), []byte("y"), DefaultContext); got != wantNoNewline {
+		t.Errorf("Unified() without trailing newline =\n%s\nwant\n%s", got, wantNoNewline)
+	}
+}
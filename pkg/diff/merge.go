@@ -0,0 +1,90 @@
+package diff
+
+import (
+	"strings"
+)
+
+// Conflict markers written around hunks that both sides changed differently
+const (
+	MarkerLocal    = "<<<<<<< local"
+	MarkerOriginal = "||||||| original"
+	MarkerSplit    = "======="
+	MarkerCatalog  = ">>>>>>> catalog"
+)
+
+// Merge3 merges the local edits and the catalog update of a file against the
+// originally installed content. Hunks changed on only one side are taken from
+// that side; hunks changed differently on both sides are written with conflict
+// markers. Returns the merged content and the number of conflicts
+func Merge3(original, local, catalog []byte) ([]byte, int) {
+	base := SplitLines(original)
+	ours := SplitLines(local)
+	theirs := SplitLines(catalog)
+
+	toLocal := matchLines(base, ours)
+	toCatalog := matchLines(base, theirs)
+
+	var out strings.Builder
+	conflicts := 0
+
+	i, a, b := 0, 0, 0
+	for i < len(base) || a < len(ours) || b < len(theirs) {
+		// Next base line that survives unchanged on both sides
+		j := i
+		for j < len(base) && (toLocal[j] < 0 || toCatalog[j] < 0) {
+			j++
+		}
+		endA, endB := len(ours), len(theirs)
+		if j < len(base) {
+			endA, endB = toLocal[j], toCatalog[j]
+		}
+
+		if j == i && endA == a && endB == b {
+			out.WriteString(base[i])
+			i, a, b = i+1, a+1, b+1
+			continue
+		}
+
+		if mergeHunk(&out, base[i:j], ours[a:endA], theirs[b:endB]) {
+			conflicts++
+		}
+		i, a, b = j, endA, endB
+	}
+
+	return []byte(out.String()), conflicts
+}
+
+// mergeHunk writes the resolution of one unstable hunk and reports whether it conflicted
+func mergeHunk(out *strings.Builder, base, ours, theirs []string) bool {
+	switch {
+	case equalLines(ours, base):
+		writeLines(out, theirs)
+		return false
+	case equalLines(theirs, base), equalLines(ours, theirs):
+		writeLines(out, ours)
+		return false
+	}
+
+	out.WriteString(MarkerLocal + "\n")
+	writeTerminated(out, ours)
+	out.WriteString(MarkerOriginal + "\n")
+	writeTerminated(out, base)
+	out.WriteString(MarkerSplit + "\n")
+	writeTerminated(out, theirs)
+	out.WriteString(MarkerCatalog + "\n")
+	return true
+}
+
+func writeLines(out *strings.Builder, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+}
+
+// writeTerminated writes lines, ending the last one with a newline so a marker can follow
+func writeTerminated(out *strings.Builder, lines []string) {
+	writeLines(out, lines)
+	if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		out.WriteString("\n")
+	}
+}
@@ -0,0 +1,125 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change
+const DefaultContext = 3
+
+// op is one line of an edit script: ' ' kept, '-' removed, '+' added
+type op struct {
+	kind byte
+	line string
+}
+
+// Unified renders a unified diff from one version of a file to another
+// Returns an empty string when the contents are identical
+func Unified(fromName, toName string, from, to []byte, context int) string {
+	a := SplitLines(from)
+	b := SplitLines(to)
+	if equalLines(a, b) {
+		return ""
+	}
+
+	ops := editScript(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n", fromName)
+	fmt.Fprintf(&out, "+++ %s\n", toName)
+
+	for start := 0; start < len(ops); {
+		// Skip to the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk while changes are within 2*context lines of each other
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+				continue
+			}
+			if i-end >= 2*context {
+				break
+			}
+		}
+
+		lo := max(start-context, 0)
+		hi := min(end+context, len(ops))
+		writeHunk(&out, ops, lo, hi)
+		start = hi
+	}
+
+	return out.String()
+}
+
+// editScript turns a line matching into the sequence of kept, removed and added lines
+func editScript(a, b []string) []op {
+	match := matchLines(a, b)
+
+	var ops []op
+	j := 0
+	for i, line := range a {
+		if match[i] < 0 {
+			ops = append(ops, op{'-', line})
+			continue
+		}
+		for ; j < match[i]; j++ {
+			ops = append(ops, op{'+', b[j]})
+		}
+		ops = append(ops, op{' ', line})
+		j++
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{'+', b[j]})
+	}
+
+	return ops
+}
+
+// writeHunk writes ops[lo:hi] with its @@ header
+func writeHunk(out *strings.Builder, ops []op, lo, hi int) {
+	// Line numbers of the hunk start in both files
+	fromLine, toLine := 1, 1
+	for _, o := range ops[:lo] {
+		if o.kind != '+' {
+			fromLine++
+		}
+		if o.kind != '-' {
+			toLine++
+		}
+	}
+
+	fromCount, toCount := 0, 0
+	for _, o := range ops[lo:hi] {
+		if o.kind != '+' {
+			fromCount++
+		}
+		if o.kind != '-' {
+			toCount++
+		}
+	}
+
+	// An empty range is numbered by the line before it
+	if fromCount == 0 {
+		fromLine--
+	}
+	if toCount == 0 {
+		toLine--
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", fromLine, fromCount, toLine, toCount)
+	for _, o := range ops[lo:hi] {
+		out.WriteByte(o.kind)
+		out.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
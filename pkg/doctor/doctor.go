@@ -1,69 +1,109 @@
 package doctor
 
 import (
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/installer"
+	"github.com/shapestone/cc-foundry/pkg/lockfile"
 	"github.com/shapestone/cc-foundry/pkg/state"
 )
 
+// Quiet suppresses progress output from Run and ApplyFixes (used for machine-readable output)
+var Quiet = false
+
 // Issue represents a detected problem
 type Issue struct {
-	Type        string // "error", "warning", "info"
-	Category    string
-	Description string
-	CanFix      bool
-	FixFunc     func() error
+	Type        string       `json:"type"` // "error", "warning", "info"
+	Category    string       `json:"category"`
+	Description string       `json:"description"`
+	CanFix      bool         `json:"can_fix"`
+	FixFunc     func() error `json:"-"`
+	FixAction   string       `json:"fix_action,omitempty"` // what FixFunc does, e.g. "delete"
+	Choices     []Fix        `json:"choices,omitempty"`    // ways to fix it when there are several; FixFunc is the first
+	Selected    int          `json:"-"`                    // index of the choice fixing it runs
+}
+
+// Fix is one way of fixing an issue
+type Fix struct {
+	Action string       `json:"action"`
+	Func   func() error `json:"-"`
 }
 
 // HealthReport contains the results of the health check
 type HealthReport struct {
-	Issues           []Issue
-	Errors           int
-	Warnings         int
-	FilesChecked     int
-	CorruptedFiles   int
-	MissingFiles     int
-	ModifiedFiles    int
-	OrphanedFiles    int
+	Issues         []Issue     `json:"issues"`
+	Errors         int         `json:"errors"`
+	Warnings       int         `json:"warnings"`
+	FilesChecked   int         `json:"files_checked"`
+	CorruptedFiles int         `json:"corrupted_files"`
+	MissingFiles   int         `json:"missing_files"`
+	ModifiedFiles  int         `json:"modified_files"`
+	OrphanedFiles  int         `json:"orphaned_files"`
+	DriftedFiles   int         `json:"drifted_files"`
+	Fixes          []FixResult `json:"fixes,omitempty"`
+}
+
+// FixResult records the outcome of applying the fix for one issue
+type FixResult struct {
+	Description string `json:"description"`
+	Fixed       bool   `json:"fixed"`
+	Error       string `json:"error,omitempty"`
 }
 
 // Run performs a comprehensive health check and returns a report
 func Run() (*HealthReport, error) {
-	report := &HealthReport{}
+	report := &HealthReport{Issues: []Issue{}}
 
-	fmt.Println("🏥 Running doctor diagnostics...")
-	fmt.Println()
+	progress("🏥 Running doctor diagnostics...\n\n")
 
 	// 1. Verify ~/.claude.json
 	if err := checkClaudeConfig(report); err != nil {
-		fmt.Println("✗ Checking Claude Code configuration (~/.claude.json)")
+		progress("✗ Checking Claude Code configuration (~/.claude.json)\n")
 		return report, err
 	}
-	fmt.Println("✓ Checking Claude Code configuration (~/.claude.json)")
+	progress("✓ Checking Claude Code configuration (~/.claude.json)\n")
 
 	// 2. Check file integrity
 	if err := checkFileIntegrity(report); err != nil {
-		fmt.Println("✗ Checking foundry-managed files")
+		progress("✗ Checking foundry-managed files\n")
 		return report, err
 	}
-	fmt.Printf("✓ Checking foundry-managed files (%d files)\n", report.FilesChecked)
+	progress("✓ Checking foundry-managed files (%d files)\n", report.FilesChecked)
 
 	// 3. Detect conflicts
 	if err := detectConflicts(report); err != nil {
-		fmt.Println("✗ Detecting orphaned and conflicting files")
+		progress("✗ Detecting orphaned and conflicting files\n")
 		return report, err
 	}
-	fmt.Println("✓ Detecting orphaned and conflicting files")
+	progress("✓ Detecting orphaned and conflicting files\n")
 
-	fmt.Println()
+	// 4. Compare the project lockfile with what is on disk
+	if err := checkLockfile(report); err != nil {
+		progress("✗ Checking project lockfile (.claude/%s)\n", lockfile.LockFile)
+		return report, err
+	}
+	progress("✓ Checking project lockfile (.claude/%s)\n", lockfile.LockFile)
+
+	progress("\n")
 	return report, nil
 }
 
+// progress prints diagnostic progress unless Quiet is set
+func progress(format string, args ...interface{}) {
+	if Quiet {
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
 // checkClaudeConfig verifies ~/.claude.json exists and is valid
 func checkClaudeConfig(report *HealthReport) error {
 	home, err := os.UserHomeDir()
@@ -128,13 +168,25 @@ func checkClaudeConfig(report *HealthReport) error {
 
 // checkFileIntegrity verifies installed files match expected hashes
 func checkFileIntegrity(report *HealthReport) error {
-	st, err := state.Load()
+	claudeDirs, err := scopeDirs()
 	if err != nil {
-		return fmt.Errorf("failed to load state: %w", err)
+		return err
 	}
 
-	if len(st.Installations) == 0 {
-		return nil
+	for _, claudeDir := range claudeDirs {
+		if err := checkScopeIntegrity(claudeDir, report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkScopeIntegrity verifies the files tracked by one .claude/ directory's state
+func checkScopeIntegrity(claudeDir string, report *HealthReport) error {
+	st, err := state.Load(claudeDir)
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
 	}
 
 	for _, inst := range st.Installations {
@@ -144,31 +196,33 @@ func checkFileIntegrity(report *HealthReport) error {
 		if _, err := os.Stat(inst.InstalledPath); os.IsNotExist(err) {
 			report.MissingFiles++
 			report.Errors++
-			report.Issues = append(report.Issues, Issue{
+			report.Issues = append(report.Issues, withFixes(Issue{
 				Type:        "error",
 				Category:    inst.Category,
 				Description: fmt.Sprintf("Missing file: %s", inst.InstalledPath),
-				CanFix:      true,
-				FixFunc:     createFixMissingFileFunc(inst),
-			})
+			}, restoreFixes(claudeDir, inst, true)))
 			continue
 		}
 
-		// Read file and check hash
+		// Read file and check hash; an unreadable or emptied file is corrupted
 		content, err := os.ReadFile(inst.InstalledPath)
-		if err != nil {
+		if err != nil || (len(content) == 0 && inst.IsModified(content)) {
+			description := fmt.Sprintf("Corrupted file: %s (empty)", inst.InstalledPath)
+			if err != nil {
+				description = fmt.Sprintf("Cannot read file %s: %v", inst.InstalledPath, err)
+			}
+			report.CorruptedFiles++
 			report.Errors++
-			report.Issues = append(report.Issues, Issue{
+			report.Issues = append(report.Issues, withFixes(Issue{
 				Type:        "error",
 				Category:    inst.Category,
-				Description: fmt.Sprintf("Cannot read file %s: %v", inst.InstalledPath, err),
-				CanFix:      false,
-			})
+				Description: description,
+			}, restoreFixes(claudeDir, inst, true)))
 			continue
 		}
 
-		currentHash := fmt.Sprintf("%x", sha256.Sum256(content))
-		if currentHash != inst.Hash {
+		// Templates are compared with what they were rendered to
+		if inst.IsModified(content) {
 			report.ModifiedFiles++
 			report.Warnings++
 			report.Issues = append(report.Issues, Issue{
@@ -178,51 +232,131 @@ func checkFileIntegrity(report *HealthReport) error {
 				CanFix:      false,
 			})
 		}
+
+		checkAssetIntegrity(claudeDir, inst, report)
 	}
 
 	return nil
 }
 
-// detectConflicts finds duplicate files or naming issues
-func detectConflicts(report *HealthReport) error {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+// checkAssetIntegrity verifies the supporting files installed with a skill package
+// Missing or unreadable ones are fixed by restoring the package, which writes back all of
+// them at once, so they are reported as a single issue
+func checkAssetIntegrity(claudeDir string, inst state.Installation, report *HealthReport) {
+	var broken []string
+	description := ""
+	for _, name := range sortedAssets(inst.Assets) {
+		report.FilesChecked++
+		assetPath := inst.AssetPath(name)
+
+		content, err := os.ReadFile(assetPath)
+		if os.IsNotExist(err) {
+			report.MissingFiles++
+			broken = append(broken, assetPath)
+			description = fmt.Sprintf("Missing file: %s (part of %s)", assetPath, inst.InstalledPath)
+			continue
+		}
+		if err != nil {
+			report.CorruptedFiles++
+			broken = append(broken, assetPath)
+			description = fmt.Sprintf("Cannot read file %s: %v", assetPath, err)
+			continue
+		}
+
+		if inst.HasAssetChanged(name, content) {
+			report.ModifiedFiles++
+			report.Warnings++
+			report.Issues = append(report.Issues, Issue{
+				Type:        "warning",
+				Category:    inst.Category,
+				Description: fmt.Sprintf("Modified file detected: %s (hash mismatch)", assetPath),
+				CanFix:      false,
+			})
+		}
 	}
 
-	// Load state to know which files are managed by foundry
-	st, err := state.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load state: %w", err)
+	if len(broken) == 0 {
+		return
+	}
+	if len(broken) > 1 {
+		description = fmt.Sprintf("Missing or unreadable files of %s: %s", inst.InstalledPath, strings.Join(broken, ", "))
 	}
+	report.Errors++
+	report.Issues = append(report.Issues, withFixes(Issue{
+		Type:        "error",
+		Category:    inst.Category,
+		Description: description,
+	}, restoreFixes(claudeDir, inst, false)))
+}
 
-	managedPaths := make(map[string]bool)
-	for _, inst := range st.Installations {
-		managedPaths[inst.InstalledPath] = true
+// sortedAssets returns the names of a package's supporting files in order
+func sortedAssets(assets map[string]string) []string {
+	names := make([]string, 0, len(assets))
+	for name := range assets {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	// Check user-level directories
-	claudeDir := filepath.Join(home, ".claude")
-	if err := detectConflictsInDir(claudeDir, managedPaths, report); err != nil {
+// detectConflicts finds duplicate files or naming issues
+func detectConflicts(report *HealthReport) error {
+	claudeDirs, err := scopeDirs()
+	if err != nil {
 		return err
 	}
 
-	// Check project-level directories
-	cwd, err := os.Getwd()
-	if err == nil {
-		projectClaudeDir := filepath.Join(cwd, ".claude")
-		if _, err := os.Stat(projectClaudeDir); err == nil {
-			if err := detectConflictsInDir(projectClaudeDir, managedPaths, report); err != nil {
-				return err
+	// Load state to know which files are managed by foundry, and the name prefixes they were installed under
+	managedPaths := make(map[string]bool)
+	var prefixes []string
+	for _, claudeDir := range claudeDirs {
+		st, err := state.Load(claudeDir)
+		if err != nil {
+			return fmt.Errorf("failed to load state: %w", err)
+		}
+		for _, inst := range st.Installations {
+			managedPaths[inst.InstalledPath] = true
+		}
+		for _, prefix := range installer.NamePrefixes(st) {
+			if !slices.Contains(prefixes, prefix) {
+				prefixes = append(prefixes, prefix)
 			}
 		}
 	}
 
+	// Check user-level and project-level directories
+	for _, claudeDir := range claudeDirs {
+		if err := detectConflictsInDir(claudeDir, managedPaths, prefixes, report); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// scopeDirs returns the .claude/ directories to check: the user directory,
+// plus the current project's directory if it exists
+func scopeDirs() ([]string, error) {
+	userDir, err := installer.GetClaudeCodeDirFor(installer.InstallModeUser)
+	if err != nil {
+		return nil, err
+	}
+	dirs := []string{userDir}
+
+	projectDir, err := installer.GetClaudeCodeDirFor(installer.InstallModeProject)
+	if err != nil {
+		return dirs, nil
+	}
+	if _, err := os.Stat(projectDir); err == nil && projectDir != userDir {
+		dirs = append(dirs, projectDir)
+	}
+
+	return dirs, nil
+}
+
 // detectConflictsInDir checks a directory for conflicts
-func detectConflictsInDir(baseDir string, managedPaths map[string]bool, report *HealthReport) error {
+// Files whose names start with one of prefixes are foundry files and must be managed
+func detectConflictsInDir(baseDir string, managedPaths map[string]bool, prefixes []string, report *HealthReport) error {
 	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
 		return nil
 	}
@@ -238,7 +372,7 @@ func detectConflictsInDir(baseDir string, managedPaths map[string]bool, report *
 			continue
 		}
 
-		// Check for orphaned ccf- files (not in state)
+		// Check for orphaned foundry files (not in state)
 		for _, entry := range entries {
 			fullPath := filepath.Join(subdirPath, entry.Name())
 
@@ -247,32 +381,17 @@ func detectConflictsInDir(baseDir string, managedPaths map[string]bool, report *
 				continue
 			}
 
-			// Check if this is a ccf- file but not managed
-			if strings.HasPrefix(entry.Name(), "ccf-") && !managedPaths[fullPath] {
+			// Check if this is a foundry file but not managed
+			isFoundryFile := slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(entry.Name(), prefix) })
+			if isFoundryFile && !managedPaths[fullPath] {
 				// For skills, check if it's a directory
 				if subdir == "skills" && entry.IsDir() {
 					skillFile := filepath.Join(fullPath, "SKILL.md")
 					if !managedPaths[skillFile] {
-						report.OrphanedFiles++
-						report.Warnings++
-						report.Issues = append(report.Issues, Issue{
-							Type:        "warning",
-							Category:    "orphaned",
-							Description: fmt.Sprintf("Orphaned foundry file: %s (not tracked in state)", fullPath),
-							CanFix:      true,
-							FixFunc:     createRemoveOrphanedFunc(fullPath, true),
-						})
+						addOrphan(baseDir, fullPath, true, report)
 					}
 				} else if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
-					report.OrphanedFiles++
-					report.Warnings++
-					report.Issues = append(report.Issues, Issue{
-						Type:        "warning",
-						Category:    "orphaned",
-						Description: fmt.Sprintf("Orphaned foundry file: %s (not tracked in state)", fullPath),
-						CanFix:      true,
-						FixFunc:     createRemoveOrphanedFunc(fullPath, false),
-					})
+					addOrphan(baseDir, fullPath, false, report)
 				}
 			}
 		}
@@ -281,12 +400,182 @@ func detectConflictsInDir(baseDir string, managedPaths map[string]bool, report *
 	return nil
 }
 
-// createFixMissingFileFunc creates a fix function for missing files
-func createFixMissingFileFunc(inst state.Installation) func() error {
+// addOrphan reports an orphaned foundry file
+// A file that matches a catalog file is fixed by adopting it; any other one is removed
+func addOrphan(baseDir, path string, isDir bool, report *HealthReport) {
+	report.OrphanedFiles++
+	report.Warnings++
+
+	adoption, err := installer.MatchUnmanaged(baseDir, path)
+	if err != nil {
+		report.Issues = append(report.Issues, Issue{
+			Type:        "warning",
+			Category:    "orphaned",
+			Description: fmt.Sprintf("Orphaned foundry file: %s (not tracked in state)", path),
+			CanFix:      true,
+			FixFunc:     createRemoveOrphanedFunc(path, isDir),
+			FixAction:   "delete",
+		})
+		return
+	}
+
+	match := embedpkg.Reference(adoption.File.Category, adoption.File.Type, adoption.File.Filename)
+	if adoption.Modified {
+		match += ", locally modified"
+	}
+	report.Issues = append(report.Issues, Issue{
+		Type:        "warning",
+		Category:    "orphaned",
+		Description: fmt.Sprintf("Orphaned foundry file: %s (not tracked in state, matches %s)", path, match),
+		CanFix:      true,
+		FixFunc:     createAdoptFunc(baseDir, adoption.Path),
+		FixAction:   "adopt",
+	})
+}
+
+// checkLockfile reports drift between .claude/cc-foundry.lock and the project's installed files
+func checkLockfile(report *HealthReport) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+
+	claudeDir := filepath.Join(cwd, ".claude")
+	if !lockfile.Exists(claudeDir) {
+		return nil
+	}
+
+	lock, err := lockfile.Load(claudeDir)
+	if err != nil {
+		report.Errors++
+		report.Issues = append(report.Issues, Issue{
+			Type:        "error",
+			Category:    "lockfile",
+			Description: fmt.Sprintf("Cannot read lockfile: %v", err),
+			CanFix:      false,
+		})
+		return nil
+	}
+
+	addDrift := func(description string) {
+		report.DriftedFiles++
+		report.Warnings++
+		report.Issues = append(report.Issues, Issue{
+			Type:        "warning",
+			Category:    "lockfile",
+			Description: description + " (run 'cc-foundry sync')",
+			CanFix:      false,
+		})
+	}
+
+	st, err := state.Load(claudeDir)
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	// Locked files must exist with the locked content
+	lockedPaths := make(map[string]bool)
+	for _, entry := range lock.Entries {
+		path := installer.FileInstalledPath(st, entry.Source, entry.Category, entry.Type, entry.File)
+		lockedPaths[path] = true
+
+		// A template rendered from the locked content is compared with what it was rendered to
+		want := entry.SHA256
+		if inst := st.FindInstallation(path); inst != nil && inst.Hash == entry.SHA256 {
+			want = inst.InstalledHash()
+		}
+
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			addDrift(fmt.Sprintf("Locked file not installed: %s", path))
+			continue
+		}
+		if err != nil {
+			addDrift(fmt.Sprintf("Cannot read locked file %s: %v", path, err))
+			continue
+		}
+		if lockfile.Hash(content) != want {
+			addDrift(fmt.Sprintf("Installed file differs from lockfile: %s", path))
+		}
+
+		for _, name := range sortedAssets(entry.Assets) {
+			hash := entry.Assets[name]
+			assetPath := filepath.Join(filepath.Dir(path), filepath.FromSlash(name))
+			content, err := os.ReadFile(assetPath)
+			switch {
+			case err != nil:
+				addDrift(fmt.Sprintf("Locked file not installed: %s", assetPath))
+			case lockfile.Hash(content) != hash:
+				addDrift(fmt.Sprintf("Installed file differs from lockfile: %s", assetPath))
+			}
+		}
+	}
+
+	// Project installations must be locked
+	for _, inst := range st.Installations {
+		if !lockedPaths[inst.InstalledPath] {
+			addDrift(fmt.Sprintf("Installed file missing from lockfile: %s", inst.InstalledPath))
+		}
+	}
+
+	return nil
+}
+
+// restoreFixes returns the ways to fix an installation whose files are missing or corrupted
+// Restoring the installed content comes first, so a repair does not also update the file;
+// with forget, dropping the installation from the state is offered last
+func restoreFixes(claudeDir string, inst state.Installation, forget bool) []Fix {
+	var fixes []Fix
+	fromCatalog, asInstalled := installer.RestoreChoices(claudeDir, inst)
+	if asInstalled {
+		fixes = append(fixes, Fix{Action: "restore the version that was installed", Func: createRestoreFunc(claudeDir, inst.InstalledPath, true)})
+	}
+	if fromCatalog {
+		action := "restore from the catalog"
+		if asInstalled {
+			action = "restore the current catalog version"
+		}
+		fixes = append(fixes, Fix{Action: action, Func: createRestoreFunc(claudeDir, inst.InstalledPath, false)})
+	}
+	if forget {
+		fixes = append(fixes, Fix{Action: "forget it (remove from state)", Func: createForgetFunc(claudeDir, inst)})
+	}
+	return fixes
+}
+
+// withFixes makes an issue fixable by the first of fixes, offering all of them as choices when there are several
+func withFixes(issue Issue, fixes []Fix) Issue {
+	if len(fixes) == 0 {
+		return issue
+	}
+	issue.CanFix = true
+	issue.FixFunc = fixes[0].Func
+	issue.FixAction = fixes[0].Action
+	if len(fixes) > 1 {
+		issue.Choices = fixes
+	}
+	return issue
+}
+
+// createRestoreFunc creates a fix function that writes an installation's files back to disk
+func createRestoreFunc(claudeDir, installedPath string, asInstalled bool) func() error {
 	return func() error {
-		// For now, just remove from state
-		// Future: could reinstall from embedded files
-		st, err := state.Load()
+		return withInstallMode(claudeDir, func() error {
+			return installer.Restore(installedPath, asInstalled)
+		})
+	}
+}
+
+// createForgetFunc creates a fix function that removes an installation from the state
+func createForgetFunc(claudeDir string, inst state.Installation) func() error {
+	return func() error {
+		lock, err := state.Lock(claudeDir)
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock()
+
+		st, err := state.Load(claudeDir)
 		if err != nil {
 			return err
 		}
@@ -305,6 +594,25 @@ func createRemoveOrphanedFunc(path string, isDir bool) func() error {
 	}
 }
 
+// createAdoptFunc creates a fix function that adopts an orphaned file into the state of claudeDir
+func createAdoptFunc(claudeDir, path string) func() error {
+	return func() error {
+		return withInstallMode(claudeDir, func() error {
+			_, err := installer.Adopt([]string{path})
+			return err
+		})
+	}
+}
+
+// withInstallMode runs fn with the installer set to the install mode owning claudeDir
+func withInstallMode(claudeDir string, fn func() error) error {
+	previous := installer.CurrentInstallMode
+	installer.CurrentInstallMode = installer.InstallModeOf(claudeDir)
+	defer func() { installer.CurrentInstallMode = previous }()
+
+	return fn()
+}
+
 // PrintReport displays the health report
 func PrintReport(report *HealthReport) {
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -329,12 +637,18 @@ func PrintReport(report *HealthReport) {
 	if report.MissingFiles > 0 {
 		fmt.Printf("Missing files: %d\n", report.MissingFiles)
 	}
+	if report.CorruptedFiles > 0 {
+		fmt.Printf("Corrupted files: %d\n", report.CorruptedFiles)
+	}
 	if report.ModifiedFiles > 0 {
-		fmt.Printf("Modified files: %d\n", report.ModifiedFiles)
+		fmt.Printf("Modified files: %d (run 'cc-foundry diff --local' to see changes)\n", report.ModifiedFiles)
 	}
 	if report.OrphanedFiles > 0 {
 		fmt.Printf("Orphaned files: %d\n", report.OrphanedFiles)
 	}
+	if report.DriftedFiles > 0 {
+		fmt.Printf("Lockfile drift: %d\n", report.DriftedFiles)
+	}
 
 	// Print issues by type
 	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -352,35 +666,140 @@ func PrintReport(report *HealthReport) {
 
 		fmt.Printf("%s [%s] %s\n", icon, issue.Category, issue.Description)
 		if issue.CanFix {
-			fmt.Println("   (can be fixed)")
+			fmt.Printf("   (can be fixed: %s)\n", fixAction(issue))
 		}
 		fmt.Println()
 	}
 }
 
-// OfferFixes prompts the user to fix issues that can be fixed
-func OfferFixes(report *HealthReport, selectOptionFunc func(string, []string) (int, error)) error {
-	fixableIssues := []Issue{}
-	for _, issue := range report.Issues {
-		if issue.CanFix && issue.FixFunc != nil {
-			fixableIssues = append(fixableIssues, issue)
+// Menus are the interactive prompts OfferFixes asks with
+type Menus struct {
+	Checklist func(prompt string, groups []installer.OptionGroup, initiallyChecked bool) ([][]int, error) // grouped multi-select
+	Select    func(prompt string, options []string) (int, error)                                          // full-screen menu
+	Confirm   func(prompt string, options []string) (int, error)                                          // menu below printed output
+}
+
+// fixGroups are the groups fixable issues are listed in, in order
+var fixGroups = []struct {
+	name  string
+	title string
+}{
+	{"missing", "Missing and corrupted files"},
+	{"orphaned", "Orphaned files"},
+	{"config", "Configuration"},
+	{"lockfile", "Lockfile"},
+}
+
+// fixGroup returns the group a fixable issue is listed in
+// File integrity issues are reported under the category of the file, so anything else is one of them
+func fixGroup(issue Issue) string {
+	switch issue.Category {
+	case "orphaned", "config", "lockfile":
+		return issue.Category
+	}
+	return "missing"
+}
+
+// fixAction describes what fixing an issue does; issues with several fixes are asked about later
+func fixAction(issue Issue) string {
+	if len(issue.Choices) > 0 {
+		actions := make([]string, len(issue.Choices))
+		for i, choice := range issue.Choices {
+			actions[i] = choice.Action
 		}
+		return strings.Join(actions, " or ")
+	}
+	if issue.FixAction != "" {
+		return issue.FixAction
 	}
+	return "fix"
+}
 
-	if len(fixableIssues) == 0 {
+// OfferFixes lets the user check which fixable issues to fix, grouped by kind, and fixes them
+// Issues with several fixes are then asked about one by one, and what will be done is summarized
+// for confirmation before anything runs
+func OfferFixes(report *HealthReport, menus Menus) error {
+	var groups []installer.OptionGroup
+	var grouped [][]Issue
+	for _, group := range fixGroups {
+		var issues []Issue
+		var options []string
+		for _, issue := range report.Issues {
+			if issue.CanFix && issue.FixFunc != nil && fixGroup(issue) == group.name {
+				issues = append(issues, issue)
+				options = append(options, fmt.Sprintf("%s → %s", issue.Description, fixAction(issue)))
+			}
+		}
+		if len(issues) > 0 {
+			groups = append(groups, installer.OptionGroup{Title: group.title, Options: options})
+			grouped = append(grouped, issues)
+		}
+	}
+
+	if len(groups) == 0 {
 		return nil
 	}
 
+	fixable := 0
+	for _, issues := range grouped {
+		fixable += len(issues)
+	}
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Printf("\n%d issue(s) can be automatically fixed.\n\n", len(fixableIssues))
+	fmt.Printf("\n%d issue(s) can be automatically fixed.\n\n", fixable)
+
+	checked, err := menus.Checklist("Select the issues to fix", groups, true)
+	if err != nil {
+		return fixPromptError(err)
+	}
+
+	// Choose how to fix each checked issue that can be fixed in several ways
+	var planned []Issue
+	for g, indices := range checked {
+		for _, i := range indices {
+			issue := grouped[g][i]
+			if len(issue.Choices) == 0 {
+				planned = append(planned, issue)
+				continue
+			}
+
+			options := make([]string, 0, len(issue.Choices)+1)
+			for _, choice := range issue.Choices {
+				options = append(options, capitalize(choice.Action))
+			}
+			options = append(options, "Leave as is")
+
+			selected, err := menus.Select(issue.Description, options)
+			if err != nil {
+				return fixPromptError(err)
+			}
+			if selected < len(issue.Choices) {
+				issue.Selected = selected
+				planned = append(planned, issue)
+			}
+		}
+	}
+
+	if len(planned) == 0 {
+		fmt.Println("No fixes selected.")
+		return nil
+	}
+
+	fmt.Println("\nFixes to apply:")
+	for _, issue := range planned {
+		action, _ := selectedFix(issue)
+		fmt.Printf("  • %s: %s\n", capitalize(action), issue.Description)
+	}
+	fmt.Println()
 
 	options := []string{
-		"Yes, fix all issues",
+		fmt.Sprintf("Yes, apply %d fix(es)", len(planned)),
 		"No, leave as is",
 	}
-
-	selected, err := selectOptionFunc("Would you like to fix these issues?", options)
-	if err != nil || selected != 0 {
+	selected, err := menus.Confirm("Apply these fixes?", options)
+	if err != nil {
+		return fixPromptError(err)
+	}
+	if selected != 0 {
 		return nil
 	}
 
@@ -388,16 +807,76 @@ func OfferFixes(report *HealthReport, selectOptionFunc func(string, []string) (i
 	fixed := 0
 	failed := 0
 
-	for _, issue := range fixableIssues {
-		if err := issue.FixFunc(); err != nil {
-			fmt.Printf("❌ Failed to fix: %s (%v)\n", issue.Description, err)
-			failed++
-		} else {
-			fmt.Printf("✓ Fixed: %s\n", issue.Description)
+	for _, result := range ApplyFixes(&HealthReport{Issues: planned}) {
+		if result.Fixed {
 			fixed++
+		} else {
+			failed++
 		}
 	}
 
 	fmt.Printf("\nFixed: %d, Failed: %d\n", fixed, failed)
 	return nil
 }
+
+// fixPromptError returns the error of a prompt that failed; cancelling a prompt fixes nothing and is not an error
+func fixPromptError(err error) error {
+	if err.Error() == "cancelled by user" {
+		fmt.Println("No fixes applied.")
+		return nil
+	}
+	return fmt.Errorf("failed to read the fixes to apply: %w", err)
+}
+
+// ApplyFixes runs the fix for every fixable issue without prompting
+// Issues with several fixes get their selected one, which is the first unless chosen otherwise
+func ApplyFixes(report *HealthReport) []FixResult {
+	var results []FixResult
+
+	for _, issue := range report.Issues {
+		if !issue.CanFix || issue.FixFunc == nil {
+			continue
+		}
+		_, fix := selectedFix(issue)
+		results = append(results, applyFix(issue.Description, fix))
+	}
+
+	return results
+}
+
+// selectedFix returns the action and function of the fix an issue is fixed with
+func selectedFix(issue Issue) (string, func() error) {
+	if issue.Selected >= 0 && issue.Selected < len(issue.Choices) {
+		choice := issue.Choices[issue.Selected]
+		return choice.Action, choice.Func
+	}
+	return fixAction(issue), issue.FixFunc
+}
+
+// applyFix runs one fix and reports its outcome
+func applyFix(description string, fix func() error) FixResult {
+	result := FixResult{Description: description}
+	if err := fix(); err != nil {
+		progress("❌ Failed to fix: %s (%v)\n", description, err)
+		result.Error = err.Error()
+	} else {
+		progress("✓ Fixed: %s\n", description)
+		result.Fixed = true
+	}
+	return result
+}
+
+// capitalize upper-cases the first letter of a fix action for use as a menu option
+func capitalize(action string) string {
+	if action == "" {
+		return action
+	}
+	return strings.ToUpper(action[:1]) + action[1:]
+}
+
+// WriteJSON writes the health report as indented JSON
+func WriteJSON(w io.Writer, report *HealthReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
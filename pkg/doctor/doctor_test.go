@@ -0,0 +1,350 @@
+package doctor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/installer"
+)
+
+// TestWriteJSON tests that reports serialize without fix functions
+func TestWriteJSON(t *testing.T) {
+	report := &HealthReport{
+		Issues: []Issue{
+			{
+				Type:        "error",
+				Category:    "development",
+				Description: "Missing file: /tmp/.claude/agents/ccf-development-test.md",
+				CanFix:      true,
+				FixFunc:     func() error { return nil },
+			},
+		},
+		Errors:       1,
+		FilesChecked: 3,
+		MissingFiles: 1,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, report); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteJSON() produced invalid JSON: %v", err)
+	}
+
+	if decoded["errors"] != float64(1) {
+		t.Errorf("errors = %v, want 1", decoded["errors"])
+	}
+
+	issues, ok := decoded["issues"].([]interface{})
+	if !ok || len(issues) != 1 {
+		t.Fatalf("issues = %v, want 1 issue", decoded["issues"])
+	}
+
+	issue := issues[0].(map[string]interface{})
+	if issue["can_fix"] != true {
+		t.Errorf("can_fix = %v, want true", issue["can_fix"])
+	}
+	if _, exists := issue["FixFunc"]; exists {
+		t.Error("FixFunc should not be serialized")
+	}
+}
+
+// TestApplyFixes tests that every fixable issue is fixed and failures are recorded
+func TestApplyFixes(t *testing.T) {
+	originalQuiet := Quiet
+	defer func() { Quiet = originalQuiet }()
+	Quiet = true
+
+	called := 0
+	report := &HealthReport{
+		Issues: []Issue{
+			{Description: "fixable", CanFix: true, FixFunc: func() error { called++; return nil }},
+			{Description: "failing", CanFix: true, FixFunc: func() error { called++; return errors.New("boom") }},
+			{Description: "not fixable", CanFix: false},
+		},
+	}
+
+	results := ApplyFixes(report)
+
+	if called != 2 {
+		t.Errorf("fix functions called %d times, want 2", called)
+	}
+	if len(results) != 2 {
+		t.Fatalf("ApplyFixes() returned %d results, want 2", len(results))
+	}
+	if !results[0].Fixed || results[0].Error != "" {
+		t.Errorf("results[0] = %+v, want fixed", results[0])
+	}
+	if results[1].Fixed || results[1].Error != "boom" {
+		t.Errorf("results[1] = %+v, want failure with error", results[1])
+	}
+}
+
+// TestOfferFixes tests that only the checked fixes run, with the chosen fix for issues that have several
+func TestOfferFixes(t *testing.T) {
+	var applied []string
+	fix := func(name string) func() error {
+		return func() error { applied = append(applied, name); return nil }
+	}
+	report := &HealthReport{
+		Issues: []Issue{
+			{Category: "orphaned", Description: "orphan x", CanFix: true, FixFunc: fix("delete x"), FixAction: "delete"},
+			withFixes(Issue{Category: "development", Description: "missing a"}, []Fix{{"restore", fix("restore a")}, {"forget", fix("forget a")}}),
+			withFixes(Issue{Category: "development", Description: "missing b"}, []Fix{{"restore", fix("restore b")}, {"forget", fix("forget b")}}),
+			{Category: "orphaned", Description: "orphan y", CanFix: true, FixFunc: fix("delete y"), FixAction: "delete"},
+			{Category: "config", Description: "not fixable"},
+		},
+	}
+
+	var groups []installer.OptionGroup
+	var asked []string
+	confirm := 0
+	menus := Menus{
+		Checklist: func(prompt string, g []installer.OptionGroup, initiallyChecked bool) ([][]int, error) {
+			groups = g
+			return [][]int{{0, 1}, {1}}, nil // both missing files, orphan y
+		},
+		Select: func(prompt string, options []string) (int, error) {
+			asked = append(asked, prompt)
+			return map[string]int{"missing a": 1, "missing b": 2}[prompt], nil // forget a, leave b
+		},
+		Confirm: func(prompt string, options []string) (int, error) {
+			return confirm, nil
+		},
+	}
+
+	if err := OfferFixes(report, menus); err != nil {
+		t.Fatalf("OfferFixes() error = %v", err)
+	}
+	if len(groups) != 2 || groups[0].Title != "Missing and corrupted files" || groups[1].Title != "Orphaned files" {
+		t.Fatalf("groups = %+v, want missing files then orphans", groups)
+	}
+	if want := []string{"orphan x → delete", "orphan y → delete"}; !slices.Equal(groups[1].Options, want) {
+		t.Errorf("orphan options = %q, want %q", groups[1].Options, want)
+	}
+	if want := []string{"missing a", "missing b"}; !slices.Equal(asked, want) {
+		t.Errorf("asked about %q, want %q", asked, want)
+	}
+	if want := []string{"forget a", "delete y"}; !slices.Equal(applied, want) {
+		t.Errorf("applied %q, want %q", applied, want)
+	}
+
+	// Declining the summary runs nothing
+	applied, confirm = nil, 1
+	if err := OfferFixes(report, menus); err != nil {
+		t.Fatalf("OfferFixes() error = %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("applied %q after declining, want nothing", applied)
+	}
+
+	// A prompt that cannot be read is an error, while cancelling one is not
+	for _, promptErr := range []error{errors.New("could not open a new TTY"), errors.New("cancelled by user")} {
+		menus.Select = func(prompt string, options []string) (int, error) { return -1, promptErr }
+		applied, confirm = nil, 0
+		err := OfferFixes(report, menus)
+		if cancelled := promptErr.Error() == "cancelled by user"; (err == nil) != cancelled || (err != nil && !errors.Is(err, promptErr)) {
+			t.Errorf("OfferFixes() with a failing prompt %q error = %v", promptErr, err)
+		}
+		if len(applied) != 0 {
+			t.Errorf("applied %q after the prompt failed, want nothing", applied)
+		}
+	}
+}
+
+// TestApplyFixesSelected tests that an issue with several fixes is fixed with its selected one
+func TestApplyFixesSelected(t *testing.T) {
+	originalQuiet := Quiet
+	defer func() { Quiet = originalQuiet }()
+	Quiet = true
+
+	var applied []string
+	fix := func(name string) func() error {
+		return func() error { applied = append(applied, name); return nil }
+	}
+	first := withFixes(Issue{Description: "missing a"}, []Fix{{"restore", fix("restore a")}, {"forget", fix("forget a")}})
+	second := withFixes(Issue{Description: "missing b"}, []Fix{{"restore", fix("restore b")}, {"forget", fix("forget b")}})
+	second.Selected = 1
+
+	ApplyFixes(&HealthReport{Issues: []Issue{first, second}})
+	if want := []string{"restore a", "forget b"}; !slices.Equal(applied, want) {
+		t.Errorf("applied %q, want %q", applied, want)
+	}
+}
+
+// TestDetectConflictsInDir tests that unmanaged files with a recorded name prefix are reported as orphans
+// and that the ones matching a catalog file are fixed by adopting them
+func TestDetectConflictsInDir(t *testing.T) {
+	originalFS := embedpkg.CategoriesFS
+	defer func() { embedpkg.CategoriesFS = originalFS }()
+	deploy := []byte("---\nname: deploy\n---\nDeploy\n")
+	embedpkg.CategoriesFS = fstest.MapFS{
+		"categories/development/commands/deploy.md": {Data: deploy},
+	}
+
+	claudeDir := t.TempDir()
+	commands := filepath.Join(claudeDir, "commands")
+	if err := os.MkdirAll(commands, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string][]byte{"acme-managed.md": nil, "acme-stray.md": nil, "acme-copy.md": deploy, "ccf-other.md": nil, "mine.md": nil} {
+		if err := os.WriteFile(filepath.Join(commands, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := &HealthReport{}
+	managed := map[string]bool{filepath.Join(commands, "acme-managed.md"): true}
+	if err := detectConflictsInDir(claudeDir, managed, []string{"acme-"}, report); err != nil {
+		t.Fatalf("detectConflictsInDir() error = %v", err)
+	}
+
+	if report.OrphanedFiles != 2 || len(report.Issues) != 2 {
+		t.Fatalf("detectConflictsInDir() found %d orphans %+v, want acme-copy.md and acme-stray.md", report.OrphanedFiles, report.Issues)
+	}
+	if issue := report.Issues[0]; !strings.Contains(issue.Description, "acme-copy.md") || !strings.Contains(issue.Description, "matches development/commands/deploy") {
+		t.Errorf("orphan %q, want acme-copy.md matched to development/commands/deploy", issue.Description)
+	}
+	if issue := report.Issues[1]; !strings.Contains(issue.Description, "acme-stray.md") || strings.Contains(issue.Description, "matches") {
+		t.Errorf("orphan %q, want acme-stray.md without a match", issue.Description)
+	}
+}
+
+// TestCheckLockfile tests that installed files are reported when they drift from the lockfile
+func TestCheckLockfile(t *testing.T) {
+	originalFS := embedpkg.CategoriesFS
+	originalMode := installer.CurrentInstallMode
+	originalHeadless := installer.Headless
+	defer func() {
+		embedpkg.CategoriesFS = originalFS
+		installer.CurrentInstallMode = originalMode
+		installer.Headless = originalHeadless
+	}()
+	embedpkg.CategoriesFS = fstest.MapFS{
+		"categories/development/commands/deploy.md": {Data: []byte("---\nname: deploy\n---\nDeploy\n")},
+		"categories/development/commands/review.md": {Data: []byte("---\nname: review\n---\nReview\n")},
+		"categories/development/commands/lint.md":   {Data: []byte("---\nname: lint\n---\nLint\n")},
+	}
+	installer.Headless = true
+	installer.CurrentInstallMode = installer.InstallModeProject
+	project := t.TempDir()
+	t.Chdir(project)
+
+	commands := filepath.Join(project, ".claude", "commands")
+	lockPath := filepath.Join(project, ".claude", "cc-foundry.lock")
+	for _, name := range []string{"deploy.md", "review.md"} {
+		if err := installer.InstallSingleFile("development", "commands", name); err != nil {
+			t.Fatalf("InstallSingleFile(%s) error = %v", name, err)
+		}
+	}
+
+	report := &HealthReport{}
+	if err := checkLockfile(report); err != nil {
+		t.Fatalf("checkLockfile() error = %v", err)
+	}
+	if report.DriftedFiles != 0 {
+		t.Fatalf("checkLockfile() on a synced project found %+v, want no drift", report.Issues)
+	}
+
+	// Edit one locked file, delete the other, and install one the lockfile will not list
+	locked, err := os.ReadFile(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := installer.InstallSingleFile("development", "commands", "lint.md"); err != nil {
+		t.Fatalf("InstallSingleFile(lint.md) error = %v", err)
+	}
+	if err := os.WriteFile(lockPath, locked, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(commands, "ccf-development-deploy.md"), []byte("edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(commands, "ccf-development-review.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	report = &HealthReport{}
+	if err := checkLockfile(report); err != nil {
+		t.Fatalf("checkLockfile() error = %v", err)
+	}
+	want := []string{
+		"Installed file differs from lockfile: " + filepath.Join(commands, "ccf-development-deploy.md"),
+		"Locked file not installed: " + filepath.Join(commands, "ccf-development-review.md"),
+		"Installed file missing from lockfile: " + filepath.Join(commands, "ccf-development-lint.md"),
+	}
+	if report.DriftedFiles != len(want) || len(report.Issues) != len(want) {
+		t.Fatalf("checkLockfile() found %d drifted files %+v, want %d", report.DriftedFiles, report.Issues, len(want))
+	}
+	for i, description := range want {
+		if !strings.HasPrefix(report.Issues[i].Description, description) {
+			t.Errorf("issue %d = %q, want %q", i, report.Issues[i].Description, description)
+		}
+	}
+}
+
+// TestCheckScopeIntegrity tests that the broken supporting files of a skill package are reported as one fixable issue
+func TestCheckScopeIntegrity(t *testing.T) {
+	originalFS := embedpkg.CategoriesFS
+	originalMode := installer.CurrentInstallMode
+	originalHeadless := installer.Headless
+	defer func() {
+		embedpkg.CategoriesFS = originalFS
+		installer.CurrentInstallMode = originalMode
+		installer.Headless = originalHeadless
+	}()
+	embedpkg.CategoriesFS = fstest.MapFS{
+		"categories/development/skills/kit/SKILL.md":     {Data: []byte("---\nname: kit\n---\nKit\n")},
+		"categories/development/skills/kit/scripts/a.sh": {Data: []byte("#!/bin/sh\n")},
+		"categories/development/skills/kit/scripts/b.sh": {Data: []byte("#!/bin/sh\necho b\n")},
+	}
+	installer.Headless = true
+	installer.CurrentInstallMode = installer.InstallModeUser
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if err := installer.InstallSingleFile("development", "skills", "kit.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+	claudeDir := filepath.Join(home, ".claude")
+	scripts := filepath.Join(claudeDir, "skills", "ccf-development-kit", "scripts")
+	for _, name := range []string{"a.sh", "b.sh"} {
+		if err := os.Remove(filepath.Join(scripts, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := &HealthReport{}
+	if err := checkScopeIntegrity(claudeDir, report); err != nil {
+		t.Fatalf("checkScopeIntegrity() error = %v", err)
+	}
+	if report.MissingFiles != 2 || report.Errors != 1 || len(report.Issues) != 1 {
+		t.Fatalf("checkScopeIntegrity() counted %d missing, %d errors, issues %+v; want 2 missing in one issue", report.MissingFiles, report.Errors, report.Issues)
+	}
+	issue := report.Issues[0]
+	if !issue.CanFix || !strings.Contains(issue.Description, filepath.Join(scripts, "a.sh")) || !strings.Contains(issue.Description, filepath.Join(scripts, "b.sh")) {
+		t.Errorf("issue = %+v, want a fixable issue naming both scripts", issue)
+	}
+
+	if err := issue.FixFunc(); err != nil {
+		t.Fatalf("FixFunc() error = %v", err)
+	}
+	report = &HealthReport{}
+	if err := checkScopeIntegrity(claudeDir, report); err != nil {
+		t.Fatalf("checkScopeIntegrity() error = %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("checkScopeIntegrity() after the fix found %+v, want none", report.Issues)
+	}
+}
@@ -0,0 +1,313 @@
+package embed
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CatalogDir is the top-level directory every catalog presents to ListCategories and friends
+const CatalogDir = "categories"
+
+// Layer combines category roots (filesystems holding <category>/<type>/*.md) into one
+// catalog filesystem with the categories/<category>/<type>/*.md layout
+// Directory listings are merged; when two roots hold the same file, the earlier one wins
+func Layer(roots ...fs.FS) fs.FS {
+	return catalogFS(roots)
+}
+
+// catalogFS is a union of category roots mounted under categories/
+type catalogFS []fs.FS
+
+// sourceRoot is the category root of a configured source, labelled with the source's name
+type sourceRoot struct {
+	fs.FS
+	name string
+}
+
+// sourceOf returns the name of the configured source a catalog path of fsys is read from
+// It is empty for the built-in catalog and for catalogs not made by Use
+func sourceOf(fsys fs.FS, name string) string {
+	layers, ok := fsys.(catalogFS)
+	if !ok {
+		return ""
+	}
+	rel, err := layers.rel("stat", name)
+	if err != nil {
+		return ""
+	}
+	for _, root := range layers {
+		if _, err := fs.Stat(root, rel); err == nil {
+			return rootName(root)
+		}
+	}
+	return ""
+}
+
+// rootName returns the name of the configured source a category root belongs to, empty for the built-in catalog
+func rootName(root fs.FS) string {
+	if source, ok := root.(sourceRoot); ok {
+		return source.name
+	}
+	return ""
+}
+
+// sourceViews returns the catalog as each of its sources presents it, in layer order
+// Without SeparateSources, or for a catalog not made by Use, the whole catalog is the only view
+func sourceViews() []fs.FS {
+	layers, ok := CategoriesFS.(catalogFS)
+	if !SeparateSources || !ok {
+		return []fs.FS{CategoriesFS}
+	}
+	views := make([]fs.FS, len(layers))
+	for i, root := range layers {
+		views[i] = catalogFS{root}
+	}
+	return views
+}
+
+// sourceView returns the catalog as one source presents it; the empty name is the built-in catalog
+func sourceView(source string) (fs.FS, bool) {
+	layers, ok := CategoriesFS.(catalogFS)
+	if !ok {
+		return CategoriesFS, source == ""
+	}
+	for _, root := range layers {
+		if rootName(root) == source {
+			return catalogFS{root}, true
+		}
+	}
+	return nil, false
+}
+
+// rel maps a catalog path to a path inside each root
+func (c catalogFS) rel(op, name string) (string, error) {
+	if name == CatalogDir {
+		return ".", nil
+	}
+	if rest, ok := strings.CutPrefix(name, CatalogDir+"/"); ok && fs.ValidPath(rest) {
+		return rest, nil
+	}
+	return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+func (c catalogFS) Open(name string) (fs.File, error) {
+	rel, err := c.rel("open", name)
+	if err != nil {
+		return nil, err
+	}
+	for _, root := range c {
+		if f, err := root.Open(rel); err == nil {
+			return f, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (c catalogFS) ReadFile(name string) ([]byte, error) {
+	rel, err := c.rel("read", name)
+	if err != nil {
+		return nil, err
+	}
+	for _, root := range c {
+		if content, err := fs.ReadFile(root, rel); err == nil {
+			return content, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+}
+
+func (c catalogFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	rel, err := c.rel("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	seen := make(map[string]bool)
+	var merged []fs.DirEntry
+	for _, root := range c {
+		entries, err := fs.ReadDir(root, rel)
+		if err != nil {
+			continue
+		}
+		found = true
+		for _, entry := range entries {
+			if !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				merged = append(merged, entry)
+			}
+		}
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name() < merged[j].Name() })
+	return merged, nil
+}
+
+// categoryRoot returns the category root of a catalog filesystem
+// Catalogs may hold categories/<category>/... or <category>/... at the top level
+func categoryRoot(fsys fs.FS) (fs.FS, error) {
+	if info, err := fs.Stat(fsys, CatalogDir); err == nil && info.IsDir() {
+		return fs.Sub(fsys, CatalogDir)
+	}
+	return fsys, nil
+}
+
+// readTarGz loads the regular files of a .tar.gz archive into memory
+func readTarGz(data []byte) (memFS, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(memFS)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("archive entry %q escapes the archive", header.Name)
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", header.Name, err)
+		}
+		files[name] = content
+	}
+
+	return files, nil
+}
+
+// memFS is a read-only in-memory filesystem of regular files; directories are implied by paths
+type memFS map[string][]byte
+
+func (m memFS) ReadFile(name string) ([]byte, error) {
+	content, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return content, nil
+}
+
+func (m memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+
+	children := make(map[string]bool) // name -> is directory
+	for file := range m {
+		rest, ok := strings.CutPrefix(file, prefix)
+		if !ok {
+			continue
+		}
+		child, _, isDir := strings.Cut(rest, "/")
+		children[child] = children[child] || isDir
+	}
+
+	if len(children) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(children))
+	for child, isDir := range children {
+		info := memInfo{name: child, dir: isDir}
+		if !isDir {
+			info.size = int64(len(m[prefix+child]))
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if content, ok := m[name]; ok {
+		return &memFile{info: memInfo{name: path.Base(name), size: int64(len(content))}, Reader: bytes.NewReader(content)}, nil
+	}
+
+	entries, err := m.ReadDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memDir{info: memInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+}
+
+// memInfo describes a memFS file or implied directory
+type memInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return i.dir }
+func (i memInfo) Sys() any           { return nil }
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+type memFile struct {
+	*bytes.Reader
+	info memInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+type memDir struct {
+	info    memInfo
+	entries []fs.DirEntry
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memDir) Close() error               { return nil }
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
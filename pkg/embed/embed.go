@@ -1,21 +1,144 @@
 package embed
 
 import (
+	"errors"
+	"fmt"
 	"io/fs"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
+// SkillFile is the entry point of a skill package: skills/<name>/SKILL.md next to its supporting files
+const SkillFile = "SKILL.md"
+
 // CategoriesFS is the embedded filesystem containing categories
 // This must be set by the main package after embedding
 var CategoriesFS fs.FS
 
+// SeparateSources keeps the files of configured sources apart instead of letting the first source
+// that has a file shadow the others: listings include every source's copy, and GetSourceFile reads
+// the copy of the source named. main sets it with naming.qualify_source, which names the copies apart
+var SeparateSources bool
+
 // CategoryFile represents a file within a category
+// A skill package is presented as <name>.md with the content of its SKILL.md
 type CategoryFile struct {
 	Category string
 	Type     string // "commands", "agents", or "skills"
 	Filename string
 	Content  []byte
+	Metadata Metadata          // parsed frontmatter; fields are empty when it is missing or invalid
+	Assets   map[string][]byte // supporting files of a skill package by slash path relative to SKILL.md
+	Source   string            // configured source the file is read from; empty for the built-in catalog
+}
+
+// newCategoryFile builds a CategoryFile read from fsys and parses its frontmatter
+func newCategoryFile(fsys fs.FS, category, fileType, filename string, content []byte) CategoryFile {
+	metadata, _ := ParseMetadata(content)
+	return CategoryFile{
+		Category: category,
+		Type:     fileType,
+		Filename: filename,
+		Content:  content,
+		Metadata: metadata,
+		Source:   sourceOf(fsys, path.Join(CatalogDir, category, fileType, filename)),
+	}
+}
+
+// readTypeEntry reads one entry of a type directory: a .md file, or for skills a package directory
+// ok is false for entries that are neither
+func readTypeEntry(fsys fs.FS, category, fileType string, entry fs.DirEntry) (file CategoryFile, ok bool, err error) {
+	typePath := filepath.Join("categories", category, fileType)
+
+	if !entry.IsDir() {
+		if !strings.HasSuffix(entry.Name(), ".md") {
+			return CategoryFile{}, false, nil
+		}
+		content, err := fs.ReadFile(fsys, filepath.Join(typePath, entry.Name()))
+		if err != nil {
+			return CategoryFile{}, false, err
+		}
+		return newCategoryFile(fsys, category, fileType, entry.Name(), content), true, nil
+	}
+
+	if fileType != "skills" {
+		return CategoryFile{}, false, nil
+	}
+	file, err = readSkillPackage(fsys, category, entry.Name())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return CategoryFile{}, false, nil // a directory without SKILL.md is not a package
+		}
+		return CategoryFile{}, false, err
+	}
+	return file, true, nil
+}
+
+// readSkillPackage reads skills/<name>/SKILL.md and every other file below skills/<name>/
+func readSkillPackage(fsys fs.FS, category, name string) (CategoryFile, error) {
+	dir := path.Join("categories", category, "skills", name)
+
+	content, err := fs.ReadFile(fsys, path.Join(dir, SkillFile))
+	if err != nil {
+		return CategoryFile{}, err
+	}
+
+	assets := make(map[string][]byte)
+	err = fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || p == path.Join(dir, SkillFile) {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		assets[strings.TrimPrefix(p, dir+"/")] = data
+		return nil
+	})
+	if err != nil {
+		return CategoryFile{}, err
+	}
+
+	file := newCategoryFile(fsys, category, "skills", name+".md", content)
+	file.Assets = assets
+	file.Source = sourceOf(fsys, path.Join(dir, SkillFile))
+	return file, nil
+}
+
+// readTypeFiles returns the files of a type directory in every source view
+// Copies of a file from several sources are listed next to each other, in source order;
+// found is false when no view has the directory
+func readTypeFiles(category, fileType string) (files []CategoryFile, found bool, err error) {
+	typePath := filepath.Join("categories", category, fileType)
+	views := sourceViews()
+
+	for _, view := range views {
+		entries, err := fs.ReadDir(view, typePath)
+		if err != nil {
+			continue
+		}
+		found = true
+
+		for _, entry := range entries {
+			file, ok, err := readTypeEntry(view, category, fileType, entry)
+			if err != nil {
+				return nil, true, err
+			}
+			if ok {
+				files = append(files, file)
+			}
+		}
+	}
+
+	if len(views) > 1 {
+		sort.SliceStable(files, func(i, j int) bool { return files[i].Filename < files[j].Filename })
+	}
+	return files, found, nil
 }
 
 // ListCategories returns all available categories
@@ -38,33 +161,13 @@ func ListCategories() ([]string, error) {
 func ListCategoryFiles(category string) ([]CategoryFile, error) {
 	var files []CategoryFile
 
-	categoryPath := filepath.Join("categories", category)
-
-	// Check each type directory (commands, agents, skills)
+	// Check each type directory (commands, agents, skills); one that doesn't exist adds nothing
 	for _, fileType := range []string{"commands", "agents", "skills"} {
-		typePath := filepath.Join(categoryPath, fileType)
-
-		entries, err := fs.ReadDir(CategoriesFS, typePath)
+		typeFiles, _, err := readTypeFiles(category, fileType)
 		if err != nil {
-			// Directory doesn't exist for this type, skip
-			continue
-		}
-
-		for _, entry := range entries {
-			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
-				content, err := fs.ReadFile(CategoriesFS, filepath.Join(typePath, entry.Name()))
-				if err != nil {
-					return nil, err
-				}
-
-				files = append(files, CategoryFile{
-					Category: category,
-					Type:     fileType,
-					Filename: entry.Name(),
-					Content:  content,
-				})
-			}
+			return nil, err
 		}
+		files = append(files, typeFiles...)
 	}
 
 	return files, nil
@@ -90,47 +193,51 @@ func ListAllFiles() ([]CategoryFile, error) {
 
 // ListTypeFiles returns all files of a specific type in a category
 func ListTypeFiles(category, fileType string) ([]CategoryFile, error) {
-	var files []CategoryFile
-
-	typePath := filepath.Join("categories", category, fileType)
-
-	entries, err := fs.ReadDir(CategoriesFS, typePath)
+	files, found, err := readTypeFiles(category, fileType)
 	if err != nil {
 		return nil, err
 	}
-
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
-			content, err := fs.ReadFile(CategoriesFS, filepath.Join(typePath, entry.Name()))
-			if err != nil {
-				return nil, err
-			}
-
-			files = append(files, CategoryFile{
-				Category: category,
-				Type:     fileType,
-				Filename: entry.Name(),
-				Content:  content,
-			})
-		}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: filepath.Join("categories", category, fileType), Err: fs.ErrNotExist}
 	}
-
 	return files, nil
 }
 
 // GetFile retrieves a specific file's content
+// For skills, <name>.md also resolves to the skill package skills/<name>/
 func GetFile(category, fileType, filename string) (*CategoryFile, error) {
+	return getFile(CategoriesFS, category, fileType, filename)
+}
+
+// GetSourceFile retrieves a file from the configured source it came from, empty for the built-in catalog
+// Sources are only told apart with SeparateSources; otherwise it is GetFile
+func GetSourceFile(source, category, fileType, filename string) (*CategoryFile, error) {
+	if !SeparateSources {
+		return GetFile(category, fileType, filename)
+	}
+	view, ok := sourceView(source)
+	if !ok {
+		return nil, fmt.Errorf("catalog source '%s' is not configured", source)
+	}
+	return getFile(view, category, fileType, filename)
+}
+
+// getFile retrieves a file from a catalog filesystem
+func getFile(fsys fs.FS, category, fileType, filename string) (*CategoryFile, error) {
 	path := filepath.Join("categories", category, fileType, filename)
 
-	content, err := fs.ReadFile(CategoriesFS, path)
+	content, err := fs.ReadFile(fsys, path)
 	if err != nil {
-		return nil, err
+		if fileType != "skills" {
+			return nil, err
+		}
+		file, packageErr := readSkillPackage(fsys, category, strings.TrimSuffix(filename, ".md"))
+		if packageErr != nil {
+			return nil, err
+		}
+		return &file, nil
 	}
 
-	return &CategoryFile{
-		Category: category,
-		Type:     fileType,
-		Filename: filename,
-		Content:  content,
-	}, nil
+	file := newCategoryFile(fsys, category, fileType, filename, content)
+	return &file, nil
 }
@@ -0,0 +1,729 @@
+package embed
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLayerMergesSources(t *testing.T) {
+	builtin := fstest.MapFS{
+		"development/commands/implement.md": {Data: []byte("builtin implement")},
+		"development/agents/reviewer.md":    {Data: []byte("builtin reviewer")},
+	}
+	team := fstest.MapFS{
+		"development/commands/implement.md": {Data: []byte("team implement")},
+		"internal/skills/our-skill.md":      {Data: []byte("team skill")},
+	}
+
+	original := CategoriesFS
+	defer func() { CategoriesFS = original }()
+	CategoriesFS = Layer(team, builtin)
+
+	categories, err := ListCategories()
+	if err != nil {
+		t.Fatalf("ListCategories() error = %v", err)
+	}
+	if len(categories) != 2 || categories[0] != "development" || categories[1] != "internal" {
+		t.Errorf("ListCategories() = %v, want [development internal]", categories)
+	}
+
+	files, err := ListCategoryFiles("development")
+	if err != nil {
+		t.Fatalf("ListCategoryFiles() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("ListCategoryFiles() returned %d files, want 2", len(files))
+	}
+
+	// The earlier source wins for the same file
+	file, err := GetFile("development", "commands", "implement.md")
+	if err != nil {
+		t.Fatalf("GetFile() error = %v", err)
+	}
+	if string(file.Content) != "team implement" {
+		t.Errorf("GetFile() content = %q, want the team version", file.Content)
+	}
+
+	// Use records the source each file is read from
+	if err := Use(builtin, []Source{{Config: SourceConfig{Name: "team"}, Root: team}}); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	for _, tt := range []struct{ fileType, filename, source string }{
+		{"commands", "implement.md", "team"},
+		{"agents", "reviewer.md", ""},
+	} {
+		if file, err := GetFile("development", tt.fileType, tt.filename); err != nil || file.Source != tt.source {
+			t.Errorf("GetFile(%s) source = %+v, %v; want %q", tt.filename, file, err, tt.source)
+		}
+	}
+}
+
+// TestSeparateSources tests that every source's copy of a file is listed and readable when sources are kept apart
+func TestSeparateSources(t *testing.T) {
+	builtin := fstest.MapFS{"development/commands/implement.md": {Data: []byte("builtin implement")}}
+	team := fstest.MapFS{
+		"development/skills/x.md":         {Data: []byte("team x")},
+		"development/skills/kit/SKILL.md": {Data: []byte("team kit")},
+		"development/skills/kit/a.sh":     {Data: []byte("team a")},
+	}
+	acme := fstest.MapFS{
+		"development/skills/x.md":         {Data: []byte("acme x")},
+		"development/skills/kit/SKILL.md": {Data: []byte("acme kit")},
+		"development/skills/kit/b.sh":     {Data: []byte("acme b")},
+	}
+
+	originalFS, originalSeparate := CategoriesFS, SeparateSources
+	defer func() { CategoriesFS, SeparateSources = originalFS, originalSeparate }()
+	sources := []Source{{Config: SourceConfig{Name: "team"}, Root: team}, {Config: SourceConfig{Name: "acme"}, Root: acme}}
+	if err := Use(builtin, sources); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+
+	listed := func() []string {
+		t.Helper()
+		files, err := ListTypeFiles("development", "skills")
+		if err != nil {
+			t.Fatalf("ListTypeFiles() error = %v", err)
+		}
+		var got []string
+		for _, file := range files {
+			got = append(got, fmt.Sprintf("%s:%s:%s:%d", file.Source, file.Filename, file.Content, len(file.Assets)))
+		}
+		return got
+	}
+
+	// Shadowed: the first source wins, and a package's files are merged across sources
+	if got, want := listed(), []string{"team:kit.md:team kit:2", "team:x.md:team x:0"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("shadowed ListTypeFiles() = %q, want %q", got, want)
+	}
+	if file, err := GetSourceFile("acme", "development", "skills", "x.md"); err != nil || string(file.Content) != "team x" {
+		t.Errorf("shadowed GetSourceFile(acme) = %+v, %v; want the team copy", file, err)
+	}
+
+	SeparateSources = true
+	want := []string{"team:kit.md:team kit:1", "acme:kit.md:acme kit:1", "team:x.md:team x:0", "acme:x.md:acme x:0"}
+	if got := listed(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("separate ListTypeFiles() = %q, want %q", got, want)
+	}
+	for source, content := range map[string]string{"team": "team x", "acme": "acme x"} {
+		if file, err := GetSourceFile(source, "development", "skills", "x.md"); err != nil || string(file.Content) != content || file.Source != source {
+			t.Errorf("GetSourceFile(%s) = %+v, %v; want %q", source, file, err, content)
+		}
+	}
+	if file, err := GetSourceFile("", "development", "commands", "implement.md"); err != nil || string(file.Content) != "builtin implement" {
+		t.Errorf("GetSourceFile() of the built-in catalog = %+v, %v", file, err)
+	}
+	if _, err := GetSourceFile("", "development", "skills", "x.md"); err == nil {
+		t.Errorf("GetSourceFile() found x.md in the built-in catalog, which does not have it")
+	}
+	if _, err := GetSourceFile("gone", "development", "skills", "x.md"); err == nil {
+		t.Errorf("GetSourceFile() of an unconfigured source succeeded")
+	}
+}
+
+func TestOpenSourceTarball(t *testing.T) {
+	// generate-manifest bundles hold <category>/<type>/<file>.md without a categories/ prefix
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	content := []byte("agent")
+	if err := tw.WriteHeader(&tar.Header{Name: "ops/agents/oncall.md", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+
+	archive := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := OpenSource(SourceConfig{Name: "bundle", Type: SourceTarball, Path: archive, AllowUnsigned: true})
+	if err != nil {
+		t.Fatalf("OpenSource() error = %v", err)
+	}
+
+	original := CategoriesFS
+	defer func() { CategoriesFS = original }()
+	CategoriesFS = Layer(root)
+
+	files, err := ListTypeFiles("ops", "agents")
+	if err != nil {
+		t.Fatalf("ListTypeFiles() error = %v", err)
+	}
+	if len(files) != 1 || files[0].Filename != "oncall.md" || string(files[0].Content) != "agent" {
+		t.Errorf("ListTypeFiles() = %+v, want ops/agents/oncall.md", files)
+	}
+}
+
+func TestOpenSourceDir(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "categories", "internal", "commands", "deploy.md")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("deploy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := OpenSource(SourceConfig{Name: "local", Type: SourceDir, Path: dir, AllowUnsigned: true})
+	if err != nil {
+		t.Fatalf("OpenSource() error = %v", err)
+	}
+
+	original := CategoriesFS
+	defer func() { CategoriesFS = original }()
+	CategoriesFS = Layer(root)
+
+	if _, err := GetFile("internal", "commands", "deploy.md"); err != nil {
+		t.Errorf("GetFile() error = %v", err)
+	}
+
+	if _, err := OpenSource(SourceConfig{Name: "missing", Type: SourceDir, Path: filepath.Join(dir, "nope"), AllowUnsigned: true}); err == nil {
+		t.Errorf("OpenSource() of a missing directory should fail")
+	}
+}
+
+// TestHostileSourceName tests that a source name cannot reach outside the cache, and a failed clone removes nothing but its own directory
+func TestHostileSourceName(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ConfigDirName)
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"../../..", "..", ".hidden", "a/b", `a\b`} {
+		config := fmt.Sprintf(`{"sources": [{"name": %q, "type": "dir", "path": "/tmp", "allow_unsigned": true}]}`, name)
+		if err := os.WriteFile(filepath.Join(configDir, ConfigFile), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "plain name") {
+			t.Errorf("LoadConfig() with source name %q error = %v, want it rejected", name, err)
+		}
+	}
+
+	// A clone that fails must leave the rest of the git cache alone
+	gitCache := filepath.Join(configDir, "cache", "git")
+	kept := filepath.Join(gitCache, "other", "kept.md")
+	if err := os.MkdirAll(filepath.Dir(kept), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(kept, []byte("kept"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := "file://" + filepath.ToSlash(filepath.Join(home, "no-such-repo"))
+	for _, name := range []string{"../../..", "broken"} {
+		if _, err := OpenSource(SourceConfig{Name: name, Type: SourceGit, URL: missing, AllowUnsigned: true}); err == nil {
+			t.Errorf("OpenSource() of git source %q should fail", name)
+		}
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("failed clone removed a file outside its own directory: %v", err)
+	}
+	if entries, _ := os.ReadDir(gitCache); len(entries) != 1 {
+		t.Errorf("failed clone left %v in the git cache, want only other", entries)
+	}
+}
+
+func TestLoadManifestBundle(t *testing.T) {
+	dir := t.TempDir()
+	// write saves a bundle of files and a manifest listing listed, which may differ
+	write := func(files, listed map[string]string) {
+		bundle, manifest := buildCatalog(t, files, listed)
+		if err := os.WriteFile(filepath.Join(dir, ManifestFile), manifest, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "bundle.tar.gz"), bundle, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	location := "file://" + filepath.ToSlash(filepath.Join(dir, ManifestFile))
+
+	files := map[string]string{
+		"ops/commands/deploy.md":   "deploy",
+		"ops/commands/unlisted.md": "not in the manifest",
+	}
+	write(files, map[string]string{"ops/commands/deploy.md": "deploy"})
+
+	root, manifest, err := LoadManifestBundle(location, Trust{AllowUnsigned: true})
+	if err != nil {
+		t.Fatalf("LoadManifestBundle() error = %v", err)
+	}
+	if manifest.Version != "1.0.0" {
+		t.Errorf("manifest version = %q, want 1.0.0", manifest.Version)
+	}
+
+	original := CategoriesFS
+	defer func() { CategoriesFS = original }()
+	CategoriesFS = Layer(root)
+
+	listed, err := ListCategoryFiles("ops")
+	if err != nil {
+		t.Fatalf("ListCategoryFiles() error = %v", err)
+	}
+	if len(listed) != 1 || listed[0].Filename != "deploy.md" || string(listed[0].Content) != "deploy" {
+		t.Errorf("ListCategoryFiles() = %+v, want only the listed ops/commands/deploy.md", listed)
+	}
+
+	// A file whose content does not match its manifest hash rejects the bundle
+	write(files, map[string]string{"ops/commands/deploy.md": "something else"})
+	if _, _, err := LoadManifestBundle(location, Trust{AllowUnsigned: true}); err == nil || !strings.Contains(err.Error(), "ops/commands/deploy.md") {
+		t.Errorf("LoadManifestBundle() with a tampered file error = %v, want a hash mismatch", err)
+	}
+
+	// So does a file the manifest lists but the bundle lacks
+	write(files, map[string]string{"ops/commands/deploy.md": "deploy", "ops/commands/gone.md": "gone"})
+	if _, _, err := LoadManifestBundle(location, Trust{AllowUnsigned: true}); err == nil || !strings.Contains(err.Error(), "missing from bundle") {
+		t.Errorf("LoadManifestBundle() with a missing file error = %v, want missing from bundle", err)
+	}
+
+	// And a bundle that does not match the manifest's bundle hash
+	write(files, map[string]string{"ops/commands/deploy.md": "deploy"})
+	if err := os.WriteFile(filepath.Join(dir, "bundle.tar.gz"), []byte("replaced"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenSource(SourceConfig{Name: "published", Type: SourceManifest, Path: filepath.Join(dir, ManifestFile), AllowUnsigned: true}); err == nil {
+		t.Errorf("OpenSource() of a manifest with a replaced bundle should fail")
+	}
+}
+
+func TestSignedSources(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	content := []byte("agent")
+	if err := tw.WriteHeader(&tar.Header{Name: "ops/agents/oncall.md", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+
+	archive := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source := SourceConfig{Name: "bundle", Type: SourceTarball, Path: archive, PublicKeys: []string{base64.StdEncoding.EncodeToString(publicKey)}}
+
+	// Unsigned content is refused unless the source allows it
+	if _, err := OpenSource(source); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("OpenSource() of an unsigned tarball error = %v, want not signed", err)
+	}
+
+	if err := os.WriteFile(archive+SignatureSuffix, Sign(privateKey, buf.Bytes()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenSource(source); err != nil {
+		t.Errorf("OpenSource() of a signed tarball error = %v", err)
+	}
+
+	// A signature by a key the source does not trust is refused
+	untrusted := source
+	untrusted.PublicKeys = []string{base64.StdEncoding.EncodeToString(otherKey)}
+	if _, err := OpenSource(untrusted); err == nil || !strings.Contains(err.Error(), "does not match any trusted key") {
+		t.Errorf("OpenSource() with an untrusted key error = %v, want a signature mismatch", err)
+	}
+
+	// Tampering after signing breaks the signature
+	if err := os.WriteFile(archive, append(buf.Bytes(), 0), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenSource(source); err == nil {
+		t.Errorf("OpenSource() of a tampered tarball should fail")
+	}
+
+	// Directory sources cannot be signed and need an explicit opt-in
+	if _, err := OpenSource(SourceConfig{Name: "local", Type: SourceDir, Path: t.TempDir()}); err == nil || !strings.Contains(err.Error(), "allow_unsigned") {
+		t.Errorf("OpenSource() of a dir without allow_unsigned error = %v, want an opt-in hint", err)
+	}
+}
+
+func TestFetchCatalog(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"ops/commands/deploy.md": "deploy"}
+	bundle, manifest := buildCatalog(t, files, files)
+
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/catalog/manifest.json":
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Write(manifest)
+		case "/catalog/manifest.json.sig":
+			w.Write(Sign(privateKey, manifest))
+		case "/catalog/bundle.tar.gz":
+			w.Write(bundle)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source := SourceConfig{
+		Name:       "remote",
+		Type:       SourceManifest,
+		URL:        server.URL + "/catalog/manifest.json",
+		PublicKeys: []string{base64.StdEncoding.EncodeToString(publicKey)},
+	}
+
+	// Nothing is read over the network until the catalog is fetched
+	if _, err := OpenSource(source); err == nil || !strings.Contains(err.Error(), "update-catalog") {
+		t.Errorf("OpenSource() before fetching error = %v, want a hint to run update-catalog", err)
+	}
+
+	result, err := FetchCatalog(source)
+	if err != nil {
+		t.Fatalf("FetchCatalog() error = %v", err)
+	}
+	if !result.Changed || result.Version != "1.0.0" {
+		t.Errorf("FetchCatalog() = %+v, want a new 1.0.0 catalog", result)
+	}
+
+	// A second fetch is answered with 304 and downloads nothing
+	result, err = FetchCatalog(source)
+	if err != nil {
+		t.Fatalf("second FetchCatalog() error = %v", err)
+	}
+	if result.Changed {
+		t.Errorf("second FetchCatalog() changed the cache, want not modified")
+	}
+	if requests["/catalog/bundle.tar.gz"] != 1 {
+		t.Errorf("bundle downloaded %d times, want 1", requests["/catalog/bundle.tar.gz"])
+	}
+
+	// Offline, the fetch fails but the cached catalog stays usable
+	server.Close()
+	if _, err := FetchCatalog(source); err == nil {
+		t.Errorf("FetchCatalog() with the server down should fail")
+	}
+	if _, ok := CachedAt(source); !ok {
+		t.Errorf("CachedAt() found no cached catalog after a failed fetch")
+	}
+
+	root, err := OpenSource(source)
+	if err != nil {
+		t.Fatalf("OpenSource() from the cache error = %v", err)
+	}
+	content, err := fs.ReadFile(root, "ops/commands/deploy.md")
+	if err != nil || string(content) != "deploy" {
+		t.Errorf("cached catalog deploy.md = %q, %v; want deploy", content, err)
+	}
+}
+
+// buildCatalog returns a bundle.tar.gz of files and a manifest.json listing listed, which may differ
+func buildCatalog(t *testing.T, files, listed map[string]string) (bundle, manifest []byte) {
+	t.Helper()
+	sum := func(data []byte) string { return fmt.Sprintf("%x", sha256.Sum256(data)) }
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+
+	m := Manifest{
+		Version:    "1.0.0",
+		Categories: map[string]CategoryFiles{},
+		Bundle:     BundleInfo{File: "bundle.tar.gz", SHA256: sum(buf.Bytes()), Size: int64(buf.Len())},
+	}
+	for name, content := range listed {
+		category := strings.Split(name, "/")[0]
+		entries := m.Categories[category]
+		entries.Commands = append(entries.Commands, FileEntry{Name: filepath.Base(name), File: name, SHA256: sum([]byte(content))})
+		m.Categories[category] = entries
+	}
+
+	manifest, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), manifest
+}
+
+func TestParseMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Metadata
+		wantErr bool
+	}{
+		{
+			name:    "skill with version and triggers",
+			content: "---\nname: project-layout-go\ndescription: Go layout\nversion: 4\nlast_updated: 2026-02-02\ntrigger_patterns:\n  - New Go project\n---\n# Body\n",
+			want:    Metadata{Name: "project-layout-go", Description: "Go layout", Version: "4", LastUpdated: "2026-02-02", TriggerPatterns: []string{"New Go project"}},
+		},
+		{
+			name:    "tools as a list",
+			content: "---\nname: oss-auditor\ntools: [Bash, Read]\n---\n",
+			want:    Metadata{Name: "oss-auditor", Tools: []string{"Bash", "Read"}},
+		},
+		{
+			name:    "tools as a comma-separated string",
+			content: "---\nname: reviewer\ntools: Read, Grep\n---\n",
+			want:    Metadata{Name: "reviewer", Tools: []string{"Read", "Grep"}},
+		},
+		{
+			name:    "requires and recommends",
+			content: "---\nname: auditor\nrequires: [skills/setup]\nrecommends: ops/agents/reviewer\n---\n",
+			want:    Metadata{Name: "auditor", Requires: []string{"skills/setup"}, Recommends: []string{"ops/agents/reviewer"}},
+		},
+		{
+			name:    "no frontmatter",
+			content: "# Just markdown\n",
+			wantErr: true,
+		},
+		{
+			name:    "unclosed frontmatter",
+			content: "---\nname: broken\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMetadata([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMetadata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ParseMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Fields before a YAML error are kept so listings still have something to show
+	got, err := ParseMetadata([]byte("---\ndescription: Kept\ntrigger_patterns:\n  - \"quoted\" + trailing\n---\n"))
+	if err == nil || got.Description != "Kept" {
+		t.Errorf("ParseMetadata() of invalid YAML = %+v, %v; want the description and an error", got, err)
+	}
+
+	file := CategoryFile{Metadata: Metadata{Version: "4", Description: "Standard Go project layout guidelines"}}
+	if summary := file.Summary(20); summary != "v4 · Standard Go pr…" {
+		t.Errorf("Summary(20) = %q", summary)
+	}
+}
+
+// TestCompareVersions verifies ordering of frontmatter versions
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"3", "4", -1},
+		{"v4", "4", 0},
+		{"10", "9", 1},
+		{"1.2", "1.2.0", 0},
+		{"1.10.0", "1.9.3", 1},
+		{"2.0-beta", "2.0-rc", -1},
+	}
+
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestParseReference verifies dependency references with and without a category
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		ref, want string
+		wantErr   bool
+	}{
+		{ref: "skills/setup", want: "oss/skills/setup.md"},
+		{ref: "development/agents/reviewer.md", want: "development/agents/reviewer.md"},
+		{ref: "setup", wantErr: true},
+		{ref: "oss/skill/setup", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		category, fileType, filename, err := ParseReference(tt.ref, "oss")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseReference(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			continue
+		}
+		if got := category + "/" + fileType + "/" + filename; err == nil && got != tt.want {
+			t.Errorf("ParseReference(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+// TestListProfiles verifies catalog profiles, config profiles, and resolving their files
+func TestListProfiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	originalFS := CategoriesFS
+	defer func() { CategoriesFS = originalFS }()
+	CategoriesFS = fstest.MapFS{
+		"categories/development/skills/layout.md":    {Data: []byte("---\nname: layout\n---\n")},
+		"categories/development/profiles/backend.md": {Data: []byte("---\nname: backend\ndescription: Backend\nfiles: [skills/layout, oss/agents/auditor]\n---\n")},
+		"categories/development/profiles/go.md":      {Data: []byte("---\nname: go\nfiles: [skills/layout]\n---\n")},
+		"categories/oss/agents/auditor.md":           {Data: []byte("---\nname: auditor\n---\n")},
+	}
+
+	config := `{"profiles": [{"name": "go", "description": "Mine", "files": ["oss/agents/auditor"]},
+	                          {"name": "broken", "files": ["oss/agents/missing"]}]}`
+	if err := os.MkdirAll(filepath.Join(home, ConfigDirName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ConfigDirName, ConfigFile), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err := ListProfiles()
+	if err != nil {
+		t.Fatalf("ListProfiles() error = %v", err)
+	}
+	var got []string
+	for _, p := range profiles {
+		got = append(got, fmt.Sprintf("%s(%s): %s", p.Name, p.Source, strings.Join(p.Files, " ")))
+	}
+	want := []string{
+		"backend(development): development/skills/layout oss/agents/auditor",
+		"go(config): oss/agents/auditor",
+		"broken(config): oss/agents/missing",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ListProfiles() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	backend, err := GetProfile("backend")
+	if err != nil {
+		t.Fatalf("GetProfile() error = %v", err)
+	}
+	if files, err := backend.Resolve(); err != nil || len(files) != 2 || files[1].Filename != "auditor.md" {
+		t.Errorf("Resolve() = %v, %v; want layout.md and auditor.md", files, err)
+	}
+	broken, _ := GetProfile("broken")
+	if _, err := broken.Resolve(); err == nil || !strings.Contains(err.Error(), "not in the catalog") {
+		t.Errorf("Resolve() of a missing member error = %v", err)
+	}
+	if _, err := GetProfile("nope"); err == nil {
+		t.Errorf("GetProfile() of an unknown profile should fail")
+	}
+}
+
+// TestSkillPackage verifies that skill directories are read with their supporting files,
+// from the catalog and from a manifest bundle
+func TestSkillPackage(t *testing.T) {
+	original := CategoriesFS
+	defer func() { CategoriesFS = original }()
+
+	skill := "---\nname: kit\ndescription: Kit\n---\nRun scripts/run.sh\n"
+	CategoriesFS = fstest.MapFS{
+		"categories/dev/skills/kit/SKILL.md":        {Data: []byte(skill)},
+		"categories/dev/skills/kit/scripts/run.sh":  {Data: []byte("#!/bin/sh\necho run\n")},
+		"categories/dev/skills/kit/reference.md":    {Data: []byte("# Reference\n")},
+		"categories/dev/skills/flat.md":             {Data: []byte("---\nname: flat\n---\n")},
+		"categories/dev/skills/not-a-package/x.txt": {Data: []byte("x")},
+	}
+
+	files, err := ListTypeFiles("dev", "skills")
+	if err != nil {
+		t.Fatalf("ListTypeFiles() error = %v", err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Filename)
+	}
+	if strings.Join(names, ",") != "flat.md,kit.md" {
+		t.Fatalf("ListTypeFiles() = %v, want [flat.md kit.md]", names)
+	}
+
+	kit, err := GetFile("dev", "skills", "kit.md")
+	if err != nil {
+		t.Fatalf("GetFile() error = %v", err)
+	}
+	if string(kit.Content) != skill || kit.Metadata.Name != "kit" {
+		t.Errorf("GetFile() content = %q, want the package SKILL.md", kit.Content)
+	}
+	if len(kit.Assets) != 2 || string(kit.Assets["scripts/run.sh"]) != "#!/bin/sh\necho run\n" || kit.Assets["reference.md"] == nil {
+		t.Errorf("GetFile() assets = %v, want scripts/run.sh and reference.md", kit.Assets)
+	}
+
+	// The same package in a manifest bundle
+	sum := func(data string) string { return fmt.Sprintf("%x", sha256.Sum256([]byte(data))) }
+	bundleFiles := map[string]string{
+		"dev/skills/kit/SKILL.md":       skill,
+		"dev/skills/kit/scripts/run.sh": "#!/bin/sh\necho run\n",
+		"dev/commands/other.md":         "other",
+	}
+	bundle, manifestData := buildCatalog(t, bundleFiles, nil)
+	manifest, err := ParseManifest(manifestData)
+	if err != nil {
+		t.Fatalf("ParseManifest() error = %v", err)
+	}
+	manifest.Categories["dev"] = CategoryFiles{Skills: []FileEntry{{
+		Name:   "kit",
+		File:   "dev/skills/kit/SKILL.md",
+		SHA256: sum(skill),
+		Assets: []AssetEntry{
+			{File: "dev/skills/kit/scripts/run.sh", SHA256: sum("#!/bin/sh\necho run\n")},
+			{File: "dev/commands/other.md", SHA256: sum("other")}, // outside the package, dropped
+		},
+	}}}
+
+	root, err := VerifyBundle(manifest, bundle)
+	if err != nil {
+		t.Fatalf("VerifyBundle() error = %v", err)
+	}
+	if _, err := fs.ReadFile(root, "dev/skills/kit/scripts/run.sh"); err != nil {
+		t.Errorf("verified bundle is missing the package asset: %v", err)
+	}
+	if _, err := fs.ReadFile(root, "dev/commands/other.md"); err == nil {
+		t.Errorf("verified bundle includes an asset listed outside its package")
+	}
+
+	manifest.Categories["dev"].Skills[0].Assets[0].SHA256 = sum("tampered")
+	if _, err := VerifyBundle(manifest, bundle); err == nil || !strings.Contains(err.Error(), "scripts/run.sh: sha256 does not match") {
+		t.Errorf("VerifyBundle() with a tampered asset error = %v, want a sha256 mismatch", err)
+	}
+}
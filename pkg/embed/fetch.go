@@ -0,0 +1,283 @@
+package embed
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// cacheMetaFile records how the cached catalog of a remote source was fetched
+const cacheMetaFile = "cache.json"
+
+// httpClient fetches remote catalogs
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// cacheMeta is the validator information kept for conditional requests
+type cacheMeta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+	Version      string    `json:"version,omitempty"`
+}
+
+// FetchResult describes the outcome of fetching a remote catalog
+type FetchResult struct {
+	Changed bool   // a new catalog was downloaded and cached
+	Version string // manifest version of the cached catalog
+}
+
+// GetCacheDir returns the download cache directory ($XDG_CACHE_HOME/cc-foundry or ~/.cache/cc-foundry)
+func GetCacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "cc-foundry"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".cache", "cc-foundry"), nil
+}
+
+// IsRemote reports whether a manifest source is fetched over HTTP(S) rather than read in place
+func (sc SourceConfig) IsRemote() bool {
+	return sc.Type == SourceManifest && isHTTP(sc.URL)
+}
+
+// CachedAt returns when the cached catalog of a remote source was fetched; ok is false without a cached copy
+func CachedAt(sc SourceConfig) (fetched time.Time, ok bool) {
+	dir, err := sourceCacheDir(sc.Name)
+	if err != nil {
+		return time.Time{}, false
+	}
+	meta, err := readCacheMeta(dir)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return meta.Fetched, true
+}
+
+// FetchCatalog downloads the manifest and bundle of a remote source into the cache
+// The manifest is requested conditionally; a new download replaces the cached
+// catalog only after it passes the source's signature and hash checks
+func FetchCatalog(sc SourceConfig) (*FetchResult, error) {
+	if !sc.IsRemote() {
+		return nil, fmt.Errorf("source '%s' is not fetched over HTTP", sc.Name)
+	}
+	trust, err := sc.Trust()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := sourceCacheDir(sc.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only ask for a 304 when there is a cached copy of this URL to fall back on
+	meta, err := readCacheMeta(dir)
+	if _, statErr := os.Stat(filepath.Join(dir, ManifestFile)); err != nil || statErr != nil || meta.URL != sc.URL {
+		meta = &cacheMeta{URL: sc.URL}
+	}
+
+	manifestData, resp, err := httpGet(sc.URL, meta)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		meta.Fetched = time.Now().UTC()
+		if err := writeCacheMeta(dir, meta); err != nil {
+			return nil, err
+		}
+		return &FetchResult{Version: meta.Version}, nil
+	}
+
+	signature, sigResp, err := httpGet(sc.URL+SignatureSuffix, nil)
+	if err != nil && (sigResp == nil || sigResp.StatusCode != http.StatusNotFound) {
+		return nil, err
+	}
+	if err := trust.Verify(sc.URL, manifestData, signature); err != nil {
+		return nil, err
+	}
+
+	manifest, err := ParseManifest(manifestData)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", sc.URL, err)
+	}
+
+	// The manifest pins the bundle hash, so a cached bundle with that hash is reused
+	bundle, err := os.ReadFile(filepath.Join(dir, manifest.Bundle.File))
+	if err != nil || fmt.Sprintf("%x", sha256.Sum256(bundle)) != manifest.Bundle.SHA256 {
+		bundleURL, err := resolveURL(sc.URL, manifest.Bundle.File)
+		if err != nil {
+			return nil, err
+		}
+		if bundle, _, err = httpGet(bundleURL, nil); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := VerifyBundle(manifest, bundle); err != nil {
+		return nil, fmt.Errorf("%s: %w", sc.URL, err)
+	}
+
+	meta = &cacheMeta{
+		URL:          sc.URL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      time.Now().UTC(),
+		Version:      manifest.Version,
+	}
+	files := map[string][]byte{ManifestFile: manifestData, manifest.Bundle.File: bundle}
+	if signature != nil {
+		files[ManifestFile+SignatureSuffix] = signature
+	}
+	if err := replaceCache(dir, files, meta); err != nil {
+		return nil, err
+	}
+
+	return &FetchResult{Changed: true, Version: manifest.Version}, nil
+}
+
+// openCachedManifest opens the last good cached catalog of a remote source
+func openCachedManifest(sc SourceConfig, trust Trust) (fs.FS, error) {
+	dir, err := sourceCacheDir(sc.Name)
+	if err != nil {
+		return nil, err
+	}
+	manifestPath := filepath.Join(dir, ManifestFile)
+	if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s has not been downloaded yet (run 'cc-foundry update-catalog')", sc.URL)
+	}
+
+	root, _, err := LoadManifestBundle(manifestPath, trust)
+	return root, err
+}
+
+// httpGet fetches a URL; with meta set, the request is conditional on the cached validators
+// Non-2xx responses other than 304 are errors, returned together with the response
+func httpGet(rawURL string, meta *cacheMeta) ([]byte, *http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	req.Header.Set("User-Agent", "cc-foundry")
+	if meta != nil {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && meta != nil {
+		return nil, resp, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, resp, fmt.Errorf("failed to fetch %s: %s", rawURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp, fmt.Errorf("failed to read %s: %w", rawURL, err)
+	}
+	return data, resp, nil
+}
+
+// replaceCache writes a complete catalog next to the cache directory and swaps it in
+func replaceCache(dir string, files map[string][]byte, meta *cacheMeta) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(dir), err)
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(staging, name), content, 0644); err != nil {
+			return fmt.Errorf("failed to write cache: %w", err)
+		}
+	}
+	if err := writeCacheMeta(staging, meta); err != nil {
+		return err
+	}
+
+	old := dir + ".old"
+	os.RemoveAll(old)
+	if err := os.Rename(dir, old); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace cache: %w", err)
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		os.Rename(old, dir)
+		return fmt.Errorf("failed to replace cache: %w", err)
+	}
+	os.RemoveAll(old)
+	return nil
+}
+
+func readCacheMeta(dir string) (*cacheMeta, error) {
+	data, err := os.ReadFile(filepath.Join(dir, cacheMetaFile))
+	if err != nil {
+		return nil, err
+	}
+	var meta cacheMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func writeCacheMeta(dir string, meta *cacheMeta) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, cacheMetaFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache metadata: %w", err)
+	}
+	return nil
+}
+
+// sourceCacheDir returns the cache directory of a remote source
+func sourceCacheDir(name string) (string, error) {
+	if err := checkSourceName(name); err != nil {
+		return "", err
+	}
+	cacheDir, err := GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, name), nil
+}
+
+// resolveURL resolves a file name relative to the URL of the manifest
+func resolveURL(base, name string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", base, err)
+	}
+	ref, err := url.Parse(name)
+	if err != nil {
+		return "", fmt.Errorf("invalid bundle file %q: %w", name, err)
+	}
+	return u.ResolveReference(ref).String(), nil
+}
+
+func isHTTP(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
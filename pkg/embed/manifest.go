@@ -0,0 +1,203 @@
+package embed
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ManifestFile is the manifest name written by scripts/generate-manifest.go next to the bundle
+const ManifestFile = "manifest.json"
+
+// Manifest describes a catalog bundle; it matches the output of scripts/generate-manifest.go
+type Manifest struct {
+	Version    string                   `json:"version"`
+	Generated  string                   `json:"generated"`
+	Categories map[string]CategoryFiles `json:"categories"`
+	Bundle     BundleInfo               `json:"bundle"`
+}
+
+// CategoryFiles lists the files of one category by type, and the profiles it defines
+type CategoryFiles struct {
+	Commands []FileEntry `json:"commands,omitempty"`
+	Agents   []FileEntry `json:"agents,omitempty"`
+	Skills   []FileEntry `json:"skills,omitempty"`
+	Profiles []FileEntry `json:"profiles,omitempty"`
+}
+
+// FileEntry is one catalog file in the manifest
+// File is the path inside the bundle: <category>/<type>/<file>.md, or <category>/skills/<name>/SKILL.md
+// for a skill package, whose supporting files are listed in Assets
+type FileEntry struct {
+	Name        string       `json:"name"`
+	File        string       `json:"file"`
+	Description string       `json:"description"`
+	SHA256      string       `json:"sha256"`
+	Assets      []AssetEntry `json:"assets,omitempty"`
+}
+
+// AssetEntry is a supporting file of a skill package; File is its path inside the bundle
+type AssetEntry struct {
+	File   string `json:"file"`
+	SHA256 string `json:"sha256"`
+}
+
+// BundleInfo identifies the bundle archive a manifest describes
+type BundleInfo struct {
+	File   string `json:"file"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// ParseManifest parses manifest.json content
+func ParseManifest(data []byte) (*Manifest, error) {
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if manifest.Bundle.File == "" || manifest.Bundle.SHA256 == "" {
+		return nil, fmt.Errorf("manifest has no bundle information")
+	}
+	if manifest.Bundle.File != path.Base(manifest.Bundle.File) {
+		return nil, fmt.Errorf("manifest bundle file %q must be a file name next to the manifest", manifest.Bundle.File)
+	}
+	return &manifest, nil
+}
+
+// LoadManifestBundle loads a manifest from a path or file:// URL, checks its signature,
+// reads the bundle next to it, and returns the verified catalog as a category root
+func LoadManifestBundle(location string, trust Trust) (fs.FS, *Manifest, error) {
+	manifestPath, err := localPath(location)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	signature, err := readSignature(manifestPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := trust.Verify(manifestPath, data, signature); err != nil {
+		return nil, nil, err
+	}
+
+	manifest, err := ParseManifest(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", manifestPath, err)
+	}
+
+	bundlePath := filepath.Join(filepath.Dir(manifestPath), manifest.Bundle.File)
+	bundle, err := os.ReadFile(bundlePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	root, err := VerifyBundle(manifest, bundle)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", bundlePath, err)
+	}
+	return root, manifest, nil
+}
+
+// VerifyBundle checks a bundle against its manifest and returns its category root
+// The bundle hash and every listed file's hash must match; files the manifest
+// does not list are left out of the catalog
+func VerifyBundle(manifest *Manifest, bundle []byte) (fs.FS, error) {
+	if hash := fmt.Sprintf("%x", sha256.Sum256(bundle)); hash != manifest.Bundle.SHA256 {
+		return nil, fmt.Errorf("bundle sha256 %s does not match manifest %s", hash, manifest.Bundle.SHA256)
+	}
+	if manifest.Bundle.Size > 0 && int64(len(bundle)) != manifest.Bundle.Size {
+		return nil, fmt.Errorf("bundle is %d bytes, manifest says %d", len(bundle), manifest.Bundle.Size)
+	}
+
+	files, err := readTarGz(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	verified := make(memFS)
+	var problems []string
+	verify := func(file, sha string) {
+		content, ok := files[file]
+		if !ok {
+			problems = append(problems, file+": listed in manifest but missing from bundle")
+			return
+		}
+		if hash := fmt.Sprintf("%x", sha256.Sum256(content)); hash != sha {
+			problems = append(problems, file+": sha256 does not match manifest")
+			return
+		}
+		verified[file] = content
+	}
+	for _, entry := range manifest.Entries() {
+		verify(entry.File, entry.SHA256)
+		for _, asset := range entry.Assets {
+			verify(asset.File, asset.SHA256)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("bundle does not match manifest:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return verified, nil
+}
+
+// Entries returns every file entry of the manifest with its path checked against its category and type
+// Entries whose path does not match where they are listed are dropped, as are
+// package assets outside their package directory
+func (m *Manifest) Entries() []FileEntry {
+	var entries []FileEntry
+	for category, files := range m.Categories {
+		for fileType, list := range map[string][]FileEntry{"commands": files.Commands, "agents": files.Agents, "skills": files.Skills, ProfilesDir: files.Profiles} {
+			for _, entry := range list {
+				entry.File = filepath.ToSlash(entry.File)
+				if !fs.ValidPath(entry.File) {
+					continue
+				}
+
+				typeDir := category + "/" + fileType
+				dir, name := path.Split(entry.File)
+				isPackage := fileType == "skills" && name == SkillFile && path.Dir(strings.TrimSuffix(dir, "/")) == typeDir
+				if !isPackage && (dir != typeDir+"/" || !strings.HasSuffix(name, ".md")) {
+					continue
+				}
+
+				var assets []AssetEntry
+				for _, asset := range entry.Assets {
+					asset.File = filepath.ToSlash(asset.File)
+					if isPackage && fs.ValidPath(asset.File) && strings.HasPrefix(asset.File, dir) {
+						assets = append(assets, asset)
+					}
+				}
+				entry.Assets = assets
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+// localPath turns a path or file:// URL into a local path
+func localPath(location string) (string, error) {
+	if !strings.Contains(location, "://") {
+		return resolvePath(location)
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", location, err)
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URL scheme '%s' in %q", u.Scheme, location)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
@@ -0,0 +1,206 @@
+package embed
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-yaml/pkg/yaml"
+)
+
+// ErrNoFrontmatter is returned by ParseMetadata for files without a --- frontmatter block
+var ErrNoFrontmatter = errors.New("no frontmatter found")
+
+// Metadata is the YAML frontmatter of a catalog file
+// Requires and Recommends reference other catalog files as <category>/<type>/<name>,
+// or <type>/<name> within the same category; Files lists the members of a profile the same way
+// Template marks content that is rendered with project variables at install time
+type Metadata struct {
+	Name            string
+	Description     string
+	Tools           []string
+	Version         string
+	LastUpdated     string
+	TriggerPatterns []string
+	Requires        []string
+	Recommends      []string
+	Files           []string
+	Template        bool
+}
+
+// rawMetadata is the frontmatter as written; lists may also be written as a comma-separated string
+type rawMetadata struct {
+	Name            string   `yaml:"name"`
+	Description     string   `yaml:"description"`
+	Tools           any      `yaml:"tools"`
+	Version         string   `yaml:"version"`
+	LastUpdated     string   `yaml:"last_updated"`
+	TriggerPatterns []string `yaml:"trigger_patterns"`
+	Requires        any      `yaml:"requires"`
+	Recommends      any      `yaml:"recommends"`
+	Files           any      `yaml:"files"`
+	Template        bool     `yaml:"template"`
+}
+
+// ParseMetadata parses the frontmatter of a catalog file
+// On a YAML error the fields parsed before it are still returned with the error
+func ParseMetadata(content []byte) (Metadata, error) {
+	frontmatter, _, ok := SplitFrontmatter(content)
+	if !ok {
+		return Metadata{}, ErrNoFrontmatter
+	}
+
+	var raw rawMetadata
+	err := yaml.Unmarshal(frontmatter, &raw)
+
+	metadata := Metadata{
+		Name:            raw.Name,
+		Description:     strings.TrimSpace(raw.Description),
+		Version:         raw.Version,
+		LastUpdated:     raw.LastUpdated,
+		TriggerPatterns: raw.TriggerPatterns,
+		Tools:           stringList(raw.Tools),
+		Requires:        stringList(raw.Requires),
+		Recommends:      stringList(raw.Recommends),
+		Files:           stringList(raw.Files),
+		Template:        raw.Template,
+	}
+
+	if err != nil {
+		return metadata, fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	return metadata, nil
+}
+
+// stringList converts a YAML list or comma-separated string to its items
+func stringList(value any) []string {
+	var items []string
+	switch value := value.(type) {
+	case string:
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	case []any:
+		for _, item := range value {
+			items = append(items, fmt.Sprint(item))
+		}
+	}
+	return items
+}
+
+// SplitFrontmatter separates the frontmatter between the leading --- lines from the body
+// ok is false when the content does not start with a closed frontmatter block
+func SplitFrontmatter(content []byte) (frontmatter, body []byte, ok bool) {
+	first, rest, found := bytes.Cut(content, []byte("\n"))
+	if !found || string(bytes.TrimSpace(first)) != "---" {
+		return nil, content, false
+	}
+
+	offset := 0
+	for offset < len(rest) {
+		line, _, _ := bytes.Cut(rest[offset:], []byte("\n"))
+		if string(bytes.TrimSpace(line)) == "---" {
+			end := min(offset+len(line)+1, len(rest))
+			return rest[:offset], rest[end:], true
+		}
+		offset += len(line) + 1
+	}
+	return nil, content, false
+}
+
+// VersionLabel returns the version as v<version>, or "" when the file has none
+func (m Metadata) VersionLabel() string {
+	return VersionLabel(m.Version)
+}
+
+// VersionLabel formats a frontmatter version as v<version>, or "" when it is empty
+func VersionLabel(version string) string {
+	if version == "" {
+		return ""
+	}
+	return "v" + strings.TrimPrefix(version, "v")
+}
+
+// CompareVersions compares two frontmatter versions such as "3", "v4", or "1.2.0"
+// Dot-separated parts are compared as numbers when both are numeric; missing parts count as 0
+// It returns -1 when a is older than b, 0 when they are equal, and 1 when a is newer
+func CompareVersions(a, b string) int {
+	aParts := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bParts := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := 0; i < max(len(aParts), len(bParts)); i++ {
+		aPart, bPart := "0", "0"
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+
+		aNum, aErr := strconv.Atoi(aPart)
+		bNum, bErr := strconv.Atoi(bPart)
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				return cmp.Compare(aNum, bNum)
+			}
+		case aPart != bPart:
+			return strings.Compare(aPart, bPart)
+		}
+	}
+	return 0
+}
+
+// ShortDescription returns the description on one line, shortened to width runes
+func (m Metadata) ShortDescription(width int) string {
+	return truncate(strings.Join(strings.Fields(m.Description), " "), width)
+}
+
+// Summary returns the version and description of a catalog file on one line, shortened to width runes
+func (f CategoryFile) Summary(width int) string {
+	var parts []string
+	if version := f.Metadata.VersionLabel(); version != "" {
+		parts = append(parts, version)
+	}
+	if f.Metadata.Description != "" {
+		parts = append(parts, f.Metadata.Description)
+	}
+	return truncate(strings.Join(strings.Fields(strings.Join(parts, " · ")), " "), width)
+}
+
+// truncate shortens s to at most width runes, ending in … when cut
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:width-1])) + "…"
+}
+
+// Reference returns the catalog reference of a file: <category>/<type>/<name>
+func Reference(category, fileType, filename string) string {
+	return category + "/" + fileType + "/" + strings.TrimSuffix(filename, ".md")
+}
+
+// ParseReference resolves a requires or recommends entry of a file in category
+// Entries are <category>/<type>/<name>, or <type>/<name> within the same category; the .md extension is optional
+func ParseReference(ref, category string) (refCategory, fileType, filename string, err error) {
+	parts := strings.Split(strings.Trim(strings.TrimSpace(ref), "/"), "/")
+	if len(parts) == 2 {
+		parts = append([]string{category}, parts...)
+	}
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("invalid reference '%s' (expected <category>/<type>/<name> or <type>/<name>)", ref)
+	}
+	switch parts[1] {
+	case "commands", "agents", "skills":
+	default:
+		return "", "", "", fmt.Errorf("invalid reference '%s' (unknown type '%s')", ref, parts[1])
+	}
+	return parts[0], parts[1], strings.TrimSuffix(parts[2], ".md") + ".md", nil
+}
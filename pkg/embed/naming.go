@@ -0,0 +1,62 @@
+package embed
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Default naming policy: ccf-<category>-<name>
+const (
+	DefaultNamePrefix    = "ccf"
+	DefaultNameSeparator = "-"
+)
+
+// NamingConfig is the naming policy for installed files: <prefix><sep>[<source><sep>]<category><sep><name>
+// The source is only added with QualifySource, and only for files from a configured source
+type NamingConfig struct {
+	Prefix        string `json:"prefix,omitempty"`         // default "ccf"
+	Separator     string `json:"separator,omitempty"`      // default "-"
+	QualifySource bool   `json:"qualify_source,omitempty"` // keep same-named files of different sources apart
+}
+
+// NamePrefix returns the start of every name under the policy: the prefix followed by the separator
+func (n NamingConfig) NamePrefix() string {
+	prefix, separator := n.Prefix, n.Separator
+	if prefix == "" {
+		prefix = DefaultNamePrefix
+	}
+	if separator == "" {
+		separator = DefaultNameSeparator
+	}
+	return prefix + separator
+}
+
+// Name returns the installed name of a catalog file without its .md extension
+func (n NamingConfig) Name(source, category, filename string) string {
+	separator := n.Separator
+	if separator == "" {
+		separator = DefaultNameSeparator
+	}
+
+	parts := []string{category, strings.TrimSuffix(filename, ".md")}
+	if n.QualifySource && source != "" {
+		parts = append([]string{source}, parts...)
+	}
+	return n.NamePrefix() + strings.Join(parts, separator)
+}
+
+// Validate reports a prefix or separator that would put installed files outside their directory
+func (n NamingConfig) Validate() error {
+	if err := checkNamePart("prefix", n.Prefix); err != nil {
+		return err
+	}
+	return checkNamePart("separator", n.Separator)
+}
+
+// checkNamePart rejects a naming value that would put installed files outside their directory
+func checkNamePart(key, value string) error {
+	if strings.ContainsAny(value, `/\`) || strings.Contains(value, "..") {
+		return fmt.Errorf("%s '%s' cannot contain a path separator or '..'", key, value)
+	}
+	return nil
+}
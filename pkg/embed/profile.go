@@ -0,0 +1,151 @@
+package embed
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ProfilesDir is the directory of a category holding profile definitions: categories/<category>/profiles/<name>.md
+const ProfilesDir = "profiles"
+
+// ProfileSourceConfig is the Source of profiles defined in the config file
+const ProfileSourceConfig = "config"
+
+// Profile is a named set of catalog files that may span categories
+// Catalog profiles are markdown files whose frontmatter lists their members under files;
+// user profiles are listed in the config file
+type Profile struct {
+	Name        string
+	Description string
+	Files       []string // members as <category>/<type>/<name>
+	Source      string   // category defining the profile, or ProfileSourceConfig
+}
+
+// ProfileConfig is a profile in the config file; its files must name their category
+type ProfileConfig struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Files       []string `json:"files"`
+}
+
+// ListProfiles returns the profiles of every category followed by the profiles in the config file
+// A config profile replaces a catalog profile of the same name, and the first category defining a name wins
+func ListProfiles() ([]Profile, error) {
+	profiles, err := catalogProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return profiles, err
+	}
+
+	for _, pc := range config.Profiles {
+		profile := Profile{
+			Name:        pc.Name,
+			Description: pc.Description,
+			Files:       normalizeReferences(pc.Files, ""),
+			Source:      ProfileSourceConfig,
+		}
+
+		replaced := false
+		for i := range profiles {
+			if profiles[i].Name == profile.Name {
+				profiles[i] = profile
+				replaced = true
+			}
+		}
+		if !replaced {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles, nil
+}
+
+// catalogProfiles reads categories/<category>/profiles/*.md; a profile is named by its filename
+func catalogProfiles() ([]Profile, error) {
+	categories, err := ListCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []Profile
+	seen := make(map[string]bool)
+	for _, category := range categories {
+		dir := path.Join(CatalogDir, category, ProfilesDir)
+		entries, err := fs.ReadDir(CategoriesFS, dir)
+		if err != nil {
+			continue // category without profiles
+		}
+
+		for _, entry := range entries {
+			name, ok := strings.CutSuffix(entry.Name(), ".md")
+			if entry.IsDir() || !ok || seen[name] {
+				continue
+			}
+			content, err := fs.ReadFile(CategoriesFS, path.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+
+			metadata, _ := ParseMetadata(content)
+			seen[name] = true
+			profiles = append(profiles, Profile{
+				Name:        name,
+				Description: metadata.Description,
+				Files:       normalizeReferences(metadata.Files, category),
+				Source:      category,
+			})
+		}
+	}
+	return profiles, nil
+}
+
+// normalizeReferences writes profile members as <category>/<type>/<name>
+// Members that are not valid references are kept as written so Resolve can report them
+func normalizeReferences(refs []string, category string) []string {
+	normalized := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		refCategory, fileType, filename, err := ParseReference(ref, category)
+		if err != nil {
+			normalized = append(normalized, ref)
+			continue
+		}
+		normalized = append(normalized, Reference(refCategory, fileType, filename))
+	}
+	return normalized
+}
+
+// GetProfile returns the profile with a name
+func GetProfile(name string) (*Profile, error) {
+	profiles, err := ListProfiles()
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return &profile, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("profile '%s' not found", name)
+}
+
+// Resolve returns the catalog files of a profile; a member that is invalid or not in the catalog is an error
+func (p Profile) Resolve() ([]CategoryFile, error) {
+	files := make([]CategoryFile, 0, len(p.Files))
+	for _, ref := range p.Files {
+		category, fileType, filename, err := ParseReference(ref, "")
+		if err != nil {
+			return nil, fmt.Errorf("profile '%s': %w", p.Name, err)
+		}
+		file, err := GetFile(category, fileType, filename)
+		if err != nil {
+			return nil, fmt.Errorf("profile '%s' lists %s, which is not in the catalog", p.Name, ref)
+		}
+		files = append(files, *file)
+	}
+	return files, nil
+}
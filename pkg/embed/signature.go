@@ -0,0 +1,83 @@
+package embed
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SignatureSuffix is appended to a signed file's name to find its detached signature
+// (manifest.json.sig, bundle.tar.gz.sig); the signature is the base64 ed25519 signature of the file
+const SignatureSuffix = ".sig"
+
+// Trust decides whether a catalog source's content is accepted
+type Trust struct {
+	PublicKeys    []ed25519.PublicKey
+	AllowUnsigned bool // accept content without checking its signature
+}
+
+// Trust returns the signature policy configured for a source
+func (sc SourceConfig) Trust() (Trust, error) {
+	trust := Trust{AllowUnsigned: sc.AllowUnsigned}
+	for _, key := range sc.PublicKeys {
+		publicKey, err := ParsePublicKey(key)
+		if err != nil {
+			return Trust{}, fmt.Errorf("source '%s': %w", sc.Name, err)
+		}
+		trust.PublicKeys = append(trust.PublicKeys, publicKey)
+	}
+	return trust, nil
+}
+
+// Verify checks a detached signature of data against the trusted keys
+// A nil signature means the content is unsigned
+func (t Trust) Verify(name string, data, signature []byte) error {
+	if t.AllowUnsigned {
+		return nil
+	}
+	if signature == nil {
+		return fmt.Errorf("%s is not signed (set \"allow_unsigned\": true on the source to use it anyway)", name)
+	}
+	if len(t.PublicKeys) == 0 {
+		return fmt.Errorf("%s is signed but the source has no \"public_keys\" to verify it with", name)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("%s has a malformed signature", name)
+	}
+	for _, key := range t.PublicKeys {
+		if ed25519.Verify(key, data, sig) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s signature does not match any trusted key", name)
+}
+
+// ParsePublicKey parses a base64-encoded ed25519 public key
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key %q (expected a base64 ed25519 public key)", s)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// Sign returns the detached signature of data in the format Verify expects
+func Sign(privateKey ed25519.PrivateKey, data []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, data)) + "\n")
+}
+
+// readSignature reads the detached signature of a file; a missing signature is nil
+func readSignature(path string) ([]byte, error) {
+	signature, err := os.ReadFile(path + SignatureSuffix)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+	return signature, nil
+}
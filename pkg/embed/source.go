@@ -0,0 +1,370 @@
+package embed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Source types
+const (
+	SourceDir      = "dir"      // local directory
+	SourceTarball  = "tarball"  // .tar.gz archive
+	SourceGit      = "git"      // git repository, cloned into the cache
+	SourceManifest = "manifest" // manifest.json plus the bundle it describes, verified by hash
+)
+
+// ConfigDirName is the cc-foundry directory under $HOME holding the config file and caches
+const ConfigDirName = ".cc-foundry"
+
+// ConfigFile is the name of the config file inside ConfigDirName
+const ConfigFile = "config.json"
+
+// Config is the cc-foundry config file (~/.cc-foundry/config.json)
+type Config struct {
+	Sources  []SourceConfig  `json:"sources"`
+	Profiles []ProfileConfig `json:"profiles,omitempty"`
+	Naming   NamingConfig    `json:"naming,omitzero"`
+}
+
+// SourceConfig describes one catalog source
+// Every source presents categories/<category>/<type>/*.md (or <category>/<type>/*.md at its root)
+type SourceConfig struct {
+	Name string `json:"name"`
+	Type string `json:"type"`           // "dir", "tarball", "git", or "manifest"
+	Path string `json:"path,omitempty"` // dir, tarball, and manifest: location; git: an existing checkout
+	URL  string `json:"url,omitempty"`  // git: repository to clone; manifest: http(s):// or file:// URL of manifest.json
+	Ref  string `json:"ref,omitempty"`  // git: branch or tag (default: the remote HEAD)
+
+	// Signature policy: manifest and tarball sources must carry a .sig made by one of
+	// PublicKeys (base64 ed25519); dir and git sources cannot be signed
+	PublicKeys    []string `json:"public_keys,omitempty"`
+	AllowUnsigned bool     `json:"allow_unsigned,omitempty"` // skip signature checks for this source
+}
+
+// Source is a catalog source opened for reading
+type Source struct {
+	Config SourceConfig
+	Root   fs.FS // <category>/<type>/*.md
+	Err    error // why the source could not be opened
+}
+
+// GetConfigDir returns the cc-foundry config directory (~/.cc-foundry)
+func GetConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ConfigDirName), nil
+}
+
+// GetConfigPath returns the path of the config file (~/.cc-foundry/config.json)
+func GetConfigPath() (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ConfigFile), nil
+}
+
+// LoadConfig reads the config file; a missing file is an empty config
+func LoadConfig() (*Config, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+	}
+
+	names := make(map[string]bool)
+	for i, sc := range config.Sources {
+		if sc.Name == "" {
+			return nil, fmt.Errorf("source %d in %s has no name", i+1, configPath)
+		}
+		if err := checkSourceName(sc.Name); err != nil {
+			return nil, fmt.Errorf("invalid source in %s: %w", configPath, err)
+		}
+		if names[sc.Name] {
+			return nil, fmt.Errorf("source '%s' is listed more than once in %s", sc.Name, configPath)
+		}
+		names[sc.Name] = true
+	}
+	if err := config.Naming.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid naming in %s: %w", configPath, err)
+	}
+	for i, pc := range config.Profiles {
+		if pc.Name == "" {
+			return nil, fmt.Errorf("profile %d in %s has no name", i+1, configPath)
+		}
+	}
+
+	return &config, nil
+}
+
+// OpenSources opens every configured source; failures are recorded in Source.Err
+func OpenSources(configs []SourceConfig) []Source {
+	sources := make([]Source, 0, len(configs))
+	for _, sc := range configs {
+		root, err := OpenSource(sc)
+		sources = append(sources, Source{Config: sc, Root: root, Err: err})
+	}
+	return sources
+}
+
+// OpenSource opens one catalog source and returns its category root
+// Content is only returned once it has passed the source's signature policy
+func OpenSource(sc SourceConfig) (fs.FS, error) {
+	trust, err := sc.Trust()
+	if err != nil {
+		return nil, err
+	}
+
+	switch sc.Type {
+	case SourceDir:
+		if !trust.AllowUnsigned {
+			return nil, unsignableError(sc)
+		}
+		dir, err := resolvePath(sc.Path)
+		if err != nil {
+			return nil, err
+		}
+		return openDir(dir)
+
+	case SourceTarball:
+		archive, err := resolvePath(sc.Path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(archive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", archive, err)
+		}
+		signature, err := readSignature(archive)
+		if err != nil {
+			return nil, err
+		}
+		if err := trust.Verify(archive, data, signature); err != nil {
+			return nil, err
+		}
+		files, err := readTarGz(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", archive, err)
+		}
+		return categoryRoot(files)
+
+	case SourceGit:
+		if !trust.AllowUnsigned {
+			return nil, unsignableError(sc)
+		}
+		dir, err := gitCheckout(sc)
+		if err != nil {
+			return nil, err
+		}
+		return openDir(dir)
+
+	case SourceManifest:
+		location := sc.URL
+		if location == "" {
+			location = sc.Path
+		}
+		if location == "" {
+			return nil, fmt.Errorf("manifest source '%s' needs a url or a path", sc.Name)
+		}
+		if sc.IsRemote() {
+			return openCachedManifest(sc, trust)
+		}
+		root, _, err := LoadManifestBundle(location, trust)
+		return root, err
+
+	default:
+		return nil, fmt.Errorf("unknown source type '%s' (expected dir, tarball, git, or manifest)", sc.Type)
+	}
+}
+
+// unsignableError explains that a source type without signatures needs an explicit opt-in
+func unsignableError(sc SourceConfig) error {
+	return fmt.Errorf("%s sources cannot be signed (set \"allow_unsigned\": true on '%s' to use it anyway)", sc.Type, sc.Name)
+}
+
+// UpdateSource refreshes a source that is fetched from elsewhere (git clones and HTTP manifests)
+// Local directories, tarballs, and manifests are read fresh on every run and need no update
+func UpdateSource(sc SourceConfig) error {
+	if sc.IsRemote() {
+		_, err := FetchCatalog(sc)
+		return err
+	}
+	if !sc.IsFetched() {
+		return nil
+	}
+
+	dir, err := gitCheckout(sc)
+	if err != nil {
+		return err
+	}
+
+	ref := sc.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := runGit(dir, "fetch", "--depth", "1", "origin", ref); err != nil {
+		return err
+	}
+	return runGit(dir, "checkout", "--detach", "FETCH_HEAD")
+}
+
+// Use layers configured sources over the built-in catalog and makes the result the active catalog
+// Sources that failed to open are skipped; earlier sources win over later ones and the built-in catalog
+func Use(builtin fs.FS, sources []Source) error {
+	var roots []fs.FS
+	for _, source := range sources {
+		if source.Err == nil {
+			roots = append(roots, sourceRoot{FS: source.Root, name: source.Config.Name})
+		}
+	}
+
+	builtinRoot, err := categoryRoot(builtin)
+	if err != nil {
+		return err
+	}
+
+	CategoriesFS = Layer(append(roots, builtinRoot)...)
+	return nil
+}
+
+// IsFetched reports whether a source is copied from elsewhere and needs UpdateSource to refresh
+func (sc SourceConfig) IsFetched() bool {
+	return (sc.Type == SourceGit && sc.Path == "") || sc.IsRemote()
+}
+
+// Location returns where a source is read from, for display
+func (sc SourceConfig) Location() string {
+	if sc.Type == SourceGit && sc.Path == "" {
+		if sc.Ref != "" {
+			return sc.URL + "@" + sc.Ref
+		}
+		return sc.URL
+	}
+	if sc.Type == SourceManifest && sc.URL != "" {
+		return sc.URL
+	}
+	return sc.Path
+}
+
+// openDir opens a local catalog directory
+func openDir(dir string) (fs.FS, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return categoryRoot(os.DirFS(dir))
+}
+
+// gitCheckout returns the working tree of a git source, cloning it into the cache on first use
+func gitCheckout(sc SourceConfig) (string, error) {
+	if sc.Path != "" {
+		return resolvePath(sc.Path)
+	}
+	if sc.URL == "" {
+		return "", fmt.Errorf("git source '%s' needs a url or a path", sc.Name)
+	}
+
+	if err := checkSourceName(sc.Name); err != nil {
+		return "", err
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(configDir, "cache", "git", sc.Name)
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return dir, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", filepath.Dir(dir), err)
+	}
+
+	// Clone next to the cache entry and move it into place, so a failed clone
+	// only ever removes the directory made for it
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), "."+sc.Name+".clone-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create directory in %s: %w", filepath.Dir(dir), err)
+	}
+	defer os.RemoveAll(tmp)
+
+	args := []string{"clone", "--depth", "1"}
+	if sc.Ref != "" {
+		args = append(args, "--branch", sc.Ref)
+	}
+	args = append(args, sc.URL, tmp)
+	if err := runGit("", args...); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return "", fmt.Errorf("failed to move clone into %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// checkSourceName rejects a source name that cannot name a cache directory, since it
+// would resolve outside the cache or to a hidden entry of it
+func checkSourceName(name string) error {
+	if name == "" || name != filepath.Base(name) || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("source name %q must be a plain name, without path separators or a leading dot", name)
+	}
+	return nil
+}
+
+// runGit runs a git command, returning its output in the error on failure
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %w\n%s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// resolvePath expands ~ and makes relative paths relative to the config directory
+func resolvePath(p string) (string, error) {
+	if p == "" {
+		return "", fmt.Errorf("source has no path")
+	}
+
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		return filepath.Join(home, p[1:]), nil
+	}
+
+	if !filepath.IsAbs(p) {
+		configDir, err := GetConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(configDir, p), nil
+	}
+
+	return p, nil
+}
@@ -0,0 +1,202 @@
+package installer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+// Adoption is a file cc-foundry does not manage, matched to the catalog file it was copied from
+type Adoption struct {
+	Path       string // installed path; SKILL.md for a skill package
+	File       embedpkg.CategoryFile
+	NamePrefix string // name prefix recorded for the adopted file
+	Modified   bool   // the file or one of its supporting files differs from the catalog
+}
+
+// MatchUnmanaged matches a file under a .claude directory to the catalog file it was copied from
+// The file is matched by the path the naming policy, or the default one, gives a catalog file,
+// and otherwise by content; a skill package may be given as its directory
+func MatchUnmanaged(claudeDir, path string) (*Adoption, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		path = filepath.Join(path, embedpkg.SkillFile)
+	}
+
+	rel, err := filepath.Rel(claudeDir, path)
+	fileType, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	if err != nil || !slices.Contains([]string{"commands", "agents", "skills"}, fileType) {
+		return nil, fmt.Errorf("%s is not in the commands, agents, or skills directory of %s", shortenHome(path), shortenHome(claudeDir))
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	files, err := embedpkg.ListAllFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		for _, policy := range []embedpkg.NamingConfig{Naming, {}} {
+			if namedPath(policy, claudeDir, file.Source, file.Category, file.Type, file.Filename) == path {
+				return newAdoption(path, file, policy.NamePrefix(), content), nil
+			}
+		}
+	}
+
+	// A copy under a name of its own is matched by its content
+	for _, file := range files {
+		if file.Type != fileType || (fileType == "skills") != (filepath.Base(path) == embedpkg.SkillFile) {
+			continue
+		}
+		if adoption := newAdoption(path, file, Naming.NamePrefix(), content); !adoption.Modified {
+			return adoption, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s does not match any catalog file", shortenHome(path))
+}
+
+// newAdoption describes adopting the file at path, holding content, as a catalog file
+func newAdoption(path string, file embedpkg.CategoryFile, namePrefix string, content []byte) *Adoption {
+	adoption := &Adoption{Path: path, File: file, NamePrefix: namePrefix}
+
+	rendered, err := installContent(file)
+	adoption.Modified = !bytes.Equal(content, file.Content) && (err != nil || !bytes.Equal(content, rendered))
+	for name, asset := range file.Assets {
+		local, err := os.ReadFile(filepath.Join(filepath.Dir(path), filepath.FromSlash(name)))
+		if err != nil || !bytes.Equal(local, asset) {
+			adoption.Modified = true
+		}
+	}
+	return adoption
+}
+
+// FindAdoptable returns the unmanaged foundry files of the current install mode that match a catalog file
+// Foundry files are the ones named with a prefix of the naming policy or of an installation
+func FindAdoptable() ([]Adoption, error) {
+	st, err := LoadState(CurrentInstallMode)
+	if err != nil {
+		return nil, err
+	}
+	prefixes := NamePrefixes(st)
+
+	var adoptions []Adoption
+	for _, fileType := range []string{"commands", "agents", "skills"} {
+		entries, err := os.ReadDir(filepath.Join(st.Dir(), fileType))
+		if err != nil {
+			continue // type directory not created yet
+		}
+
+		for _, entry := range entries {
+			if !slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(entry.Name(), prefix) }) {
+				continue
+			}
+			path := filepath.Join(st.Dir(), fileType, entry.Name())
+			if entry.IsDir() {
+				path = filepath.Join(path, embedpkg.SkillFile)
+			}
+			if st.FindInstallation(path) != nil {
+				continue
+			}
+			if adoption, err := MatchUnmanaged(st.Dir(), path); err == nil {
+				adoptions = append(adoptions, *adoption)
+			}
+		}
+	}
+	return adoptions, nil
+}
+
+// Adopt registers unmanaged files of the current install mode in the state without rewriting them
+// Each file is recorded as installed from its catalog match, so a copy that differs from the
+// catalog shows up as locally modified; for project installs it is also added to the lockfile
+func Adopt(paths []string) ([]Adoption, error) {
+	st, unlock, err := lockState(CurrentInstallMode)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+
+	var adoptions []Adoption
+	var files []embedpkg.CategoryFile
+	for _, path := range paths {
+		adoption, err := MatchUnmanaged(st.Dir(), path)
+		if err != nil {
+			return nil, err
+		}
+		if err := adoptFile(st, *adoption); err != nil {
+			return nil, err
+		}
+		adoptions = append(adoptions, *adoption)
+		files = append(files, adoption.File)
+	}
+
+	if err := commitChanges(tx, files, nil); err != nil {
+		return nil, err
+	}
+	return adoptions, nil
+}
+
+// adoptFile records an adoption in the state as if its catalog file had been installed at its path
+func adoptFile(st *state.State, adoption Adoption) error {
+	file := adoption.File
+	ref := embedpkg.Reference(file.Category, file.Type, file.Filename)
+	if st.FindInstallation(adoption.Path) != nil {
+		return fmt.Errorf("%s is already managed by cc-foundry", shortenHome(adoption.Path))
+	}
+	if inst := findInstalled(st, file.Source, file.Category, file.Type, file.Filename); inst != nil {
+		return fmt.Errorf("cannot adopt %s: %s is already installed as %s", shortenHome(adoption.Path), ref, shortenHome(inst.InstalledPath))
+	}
+
+	rendered, err := installContent(file)
+	if err != nil {
+		return err
+	}
+
+	return recordInstallation(st, file, adoption.Path, rendered, adoption.NamePrefix, nil)
+}
+
+// PrintAdoptPreview prints the files adopting will register and the catalog files they match
+func PrintAdoptPreview(adoptions []Adoption) {
+	ShowBanner()
+	fmt.Printf("Preview: Adopt unmanaged files [%s]\n", GetInstallModeDescription())
+	fmt.Println()
+
+	for _, adoption := range adoptions {
+		file := adoption.File
+		note := ""
+		if adoption.Modified {
+			note = " (locally modified)"
+		}
+		fmt.Printf("  + %s: %s → %s%s\n", strings.TrimSuffix(file.Type, "s"), shortenHome(adoption.Path), embedpkg.Reference(file.Category, file.Type, file.Filename), note)
+	}
+
+	fmt.Println()
+	fmt.Printf("Summary: %d files will be adopted\n", len(adoptions))
+	fmt.Println()
+}
+
+// InstallModeOf returns the install mode owning a .claude directory
+func InstallModeOf(claudeDir string) InstallMode {
+	if userDir, err := GetClaudeCodeDirFor(InstallModeUser); err == nil && userDir == claudeDir {
+		return InstallModeUser
+	}
+	return InstallModeProject
+}
@@ -0,0 +1,164 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+// InstallPlan is the ordered list of files an install writes: every file comes after the files it requires
+type InstallPlan struct {
+	Files       []embedpkg.CategoryFile
+	RequiredBy  map[string]string // reference of a pulled-in dependency -> reference of the file requiring it
+	Recommended []string          // references recommended by planned files that are neither planned nor installed
+}
+
+// parseReference resolves a requires or recommends entry of a file in category to a selector for one file
+func parseReference(ref, category string) (Selector, error) {
+	refCategory, fileType, filename, err := embedpkg.ParseReference(ref, category)
+	if err != nil {
+		return Selector{}, err
+	}
+	return Selector{Category: refCategory, Type: fileType, File: filename}, nil
+}
+
+// PlanInstall orders files for installation and pulls in the files they require
+// Required files already installed in the state are left alone; a requirement
+// missing from the catalog or a dependency cycle is an error
+func PlanInstall(st *state.State, files []embedpkg.CategoryFile) (*InstallPlan, error) {
+	plan := &InstallPlan{RequiredBy: make(map[string]string)}
+
+	selected := make(map[string]bool)
+	for _, file := range files {
+		selected[embedpkg.Reference(file.Category, file.Type, file.Filename)] = true
+	}
+
+	planned := make(map[string]bool)
+	visiting := make(map[string]bool)
+	var chain []string
+	var recommended []string
+
+	var visit func(file embedpkg.CategoryFile) error
+	visit = func(file embedpkg.CategoryFile) error {
+		ref := embedpkg.Reference(file.Category, file.Type, file.Filename)
+		key := file.Source + ":" + ref // copies from separate sources are planned apart
+		if planned[key] {
+			return nil
+		}
+		if visiting[key] {
+			return fmt.Errorf("dependency cycle: %s → %s", strings.Join(chain, " → "), ref)
+		}
+		visiting[key] = true
+		chain = append(chain, ref)
+
+		for _, entry := range file.Metadata.Requires {
+			sel, err := parseReference(entry, file.Category)
+			if err != nil {
+				return fmt.Errorf("%s requires: %w", ref, err)
+			}
+			depRef := embedpkg.Reference(sel.Category, sel.Type, sel.File)
+			if !selected[depRef] && isInstalled(st, sel) {
+				continue
+			}
+
+			dep, err := embedpkg.GetFile(sel.Category, sel.Type, sel.File)
+			if err != nil {
+				return fmt.Errorf("%s requires %s, which is not in the catalog", ref, depRef)
+			}
+			if !selected[depRef] && plan.RequiredBy[depRef] == "" {
+				plan.RequiredBy[depRef] = ref
+			}
+			if err := visit(*dep); err != nil {
+				return err
+			}
+		}
+
+		for _, entry := range file.Metadata.Recommends {
+			if sel, err := parseReference(entry, file.Category); err == nil && !isInstalled(st, sel) {
+				recommended = append(recommended, embedpkg.Reference(sel.Category, sel.Type, sel.File))
+			}
+		}
+
+		chain = chain[:len(chain)-1]
+		visiting[key] = false
+		planned[key] = true
+		plan.Files = append(plan.Files, file)
+		return nil
+	}
+
+	for _, file := range files {
+		if err := visit(file); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, ref := range recommended {
+		if !planned[ref] && !seen[ref] {
+			seen[ref] = true
+			plan.Recommended = append(plan.Recommended, ref)
+		}
+	}
+	return plan, nil
+}
+
+// isInstalled reports whether the file a selector names is installed in a state
+func isInstalled(st *state.State, sel Selector) bool {
+	return findSelected(st, sel) != nil
+}
+
+// BrokenDependents describes the installed files that require one of the installations being removed
+func BrokenDependents(st *state.State, removing []state.Installation) []string {
+	removed := make(map[string]bool)
+	for _, inst := range removing {
+		removed[inst.InstalledPath] = true
+	}
+
+	var broken []string
+	for _, inst := range st.Installations {
+		if removed[inst.InstalledPath] {
+			continue
+		}
+		for _, entry := range installedMetadata(&inst, st.Dir()).Requires {
+			sel, err := parseReference(entry, inst.Category)
+			if err != nil {
+				continue
+			}
+			if dep := findSelected(st, sel); dep != nil && removed[dep.InstalledPath] {
+				broken = append(broken, fmt.Sprintf("%s requires %s",
+					embedpkg.Reference(inst.Category, inst.Type, inst.File), embedpkg.Reference(sel.Category, sel.Type, sel.File)))
+			}
+		}
+	}
+	return broken
+}
+
+// warnBrokenDependents prints a warning for every installed file a removal leaves without a requirement
+func warnBrokenDependents(st *state.State, removing []state.Installation) {
+	broken := BrokenDependents(st, removing)
+	if len(broken) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("  ⚠ Still-installed files depend on files being removed:")
+	for _, line := range broken {
+		fmt.Printf("      %s\n", line)
+	}
+}
+
+// installedMetadata returns the frontmatter an installation was installed with
+// It is read from the stored original, or from the installed file for installs made before originals were kept
+func installedMetadata(inst *state.Installation, claudeDir string) embedpkg.Metadata {
+	content, err := inst.LoadOriginal(claudeDir)
+	if err != nil {
+		if content, err = os.ReadFile(inst.InstalledPath); err != nil {
+			return embedpkg.Metadata{}
+		}
+	}
+	metadata, _ := embedpkg.ParseMetadata(content)
+	return metadata
+}
@@ -0,0 +1,226 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/shapestone/cc-foundry/pkg/diff"
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+// UpdateDiff renders what installing the catalog content would change in an installed file
+// catalog is the content as it would be written, after rendering a template
+func UpdateDiff(inst state.Installation, catalog []byte) (string, error) {
+	installed, err := os.ReadFile(inst.InstalledPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read %s: %w", inst.InstalledPath, err)
+	}
+
+	return diff.Unified(
+		shortenHome(inst.InstalledPath)+" (installed)",
+		fmt.Sprintf("%s/%s/%s (catalog)", inst.Category, inst.Type, inst.File),
+		installed, catalog, diff.DefaultContext,
+	), nil
+}
+
+// LocalDiff renders the edits made to an installed file since it was installed
+func LocalDiff(inst state.Installation, claudeDir string) (string, error) {
+	original, err := inst.LoadInstalled(claudeDir)
+	if os.IsNotExist(err) {
+		return fmt.Sprintf("# %s: original content unknown (installed before originals were kept)\n", shortenHome(inst.InstalledPath)), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read original of %s: %w", inst.InstalledPath, err)
+	}
+
+	installed, err := os.ReadFile(inst.InstalledPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read %s: %w", inst.InstalledPath, err)
+	}
+
+	return diff.Unified(
+		shortenHome(inst.InstalledPath)+" (original)",
+		shortenHome(inst.InstalledPath)+" (installed)",
+		original, installed, diff.DefaultContext,
+	), nil
+}
+
+// SelectionDiffs renders the diffs of the installed files matched by a selector in the current mode
+// With local set it shows edits made since install, otherwise what an update from the catalog would change
+func SelectionDiffs(sel Selector, local bool) (string, error) {
+	installations, err := ListSelectedInstallations(sel)
+	if err != nil {
+		return "", err
+	}
+
+	claudeDir, err := GetClaudeCodeDir()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, inst := range installations {
+		var text string
+		if local {
+			text, err = LocalDiff(inst, claudeDir)
+		} else {
+			file, lookupErr := embedpkg.GetSourceFile(inst.Source, inst.Category, inst.Type, inst.File)
+			if lookupErr != nil {
+				continue // no longer in the catalog, nothing to update to
+			}
+			var rendered []byte
+			if rendered, err = installContent(*file); err == nil {
+				text, err = UpdateDiff(inst, rendered)
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(text)
+	}
+
+	return sb.String(), nil
+}
+
+// catalogUpdateDiffs renders the diffs of the files an install would update
+func catalogUpdateDiffs(files []embedpkg.CategoryFile) (string, error) {
+	st, err := LoadState(CurrentInstallMode)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, file := range files {
+		existing := findInstalled(st, file.Source, file.Category, file.Type, file.Filename)
+		if existing == nil || !existing.HasContentChanged(file.Content) {
+			continue
+		}
+
+		rendered, err := installContent(file)
+		if err != nil {
+			return "", err
+		}
+		text, err := UpdateDiff(*existing, rendered)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(text)
+	}
+
+	return sb.String(), nil
+}
+
+// ModifiedFilesDiff renders the local edits of every modified file in both locations
+func ModifiedFilesDiff() (string, error) {
+	var sb strings.Builder
+	for _, mode := range []InstallMode{InstallModeUser, InstallModeProject} {
+		st, err := LoadState(mode)
+		if err != nil {
+			return "", err
+		}
+
+		for _, inst := range st.Installations {
+			if !hasLocalChanges(&inst) {
+				continue
+			}
+			text, err := LocalDiff(inst, st.Dir())
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(text)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// shortenHome replaces the home directory prefix of a path with ~
+func shortenHome(path string) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		return strings.Replace(path, home, "~", 1)
+	}
+	return path
+}
+
+// diffViewModel is a scrollable, colored view of a unified diff
+type diffViewModel struct {
+	title  string
+	lines  []string
+	offset int
+	height int
+}
+
+func (m diffViewModel) Init() tea.Cmd { return nil }
+
+func (m diffViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Leave room for the title and help lines
+		m.height = max(msg.Height-4, 1)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c", "enter":
+			return m, tea.Quit
+		case "up", "k":
+			m.offset--
+		case "down", "j":
+			m.offset++
+		case "pgup", "b":
+			m.offset -= m.height
+		case "pgdown", "f", " ":
+			m.offset += m.height
+		case "home", "g":
+			m.offset = 0
+		case "end", "G":
+			m.offset = len(m.lines)
+		}
+	}
+
+	m.offset = min(m.offset, len(m.lines)-m.height)
+	m.offset = max(m.offset, 0)
+	return m, nil
+}
+
+func (m diffViewModel) View() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(m.title) + "\n")
+
+	end := min(m.offset+m.height, len(m.lines))
+	for _, line := range m.lines[m.offset:end] {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			sb.WriteString(diffHeaderStyle.Render(line))
+		case strings.HasPrefix(line, "@@"):
+			sb.WriteString(diffHunkStyle.Render(line))
+		case strings.HasPrefix(line, "+"):
+			sb.WriteString(diffAddStyle.Render(line))
+		case strings.HasPrefix(line, "-"):
+			sb.WriteString(diffRemoveStyle.Render(line))
+		default:
+			sb.WriteString(line)
+		}
+		sb.WriteString("\n")
+	}
+
+	position := fmt.Sprintf("lines %d-%d of %d", min(m.offset+1, len(m.lines)), end, len(m.lines))
+	sb.WriteString(helpStyle.Render("↑/↓: Scroll • PgUp/PgDn: Page • q: Back • " + position))
+	return sb.String()
+}
+
+// ShowDiff displays a unified diff in a full-screen scrollable viewer
+func ShowDiff(title, text string) error {
+	m := diffViewModel{
+		title:  title,
+		lines:  strings.Split(strings.TrimSuffix(text, "\n"), "\n"),
+		height: 20,
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("error running diff viewer: %w", err)
+	}
+	return nil
+}
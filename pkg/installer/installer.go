@@ -1,6 +1,7 @@
 package installer
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -21,9 +22,17 @@ const (
 // CurrentInstallMode is the active installation mode (default: user-level)
 var CurrentInstallMode = InstallModeUser
 
+// Headless disables screen clearing and the banner for non-interactive (CLI) use
+var Headless = false
+
 // GetClaudeCodeDir returns the Claude Code directory path based on install mode
 func GetClaudeCodeDir() (string, error) {
-	if CurrentInstallMode == InstallModeProject {
+	return GetClaudeCodeDirFor(CurrentInstallMode)
+}
+
+// GetClaudeCodeDirFor returns the Claude Code directory path for a specific install mode
+func GetClaudeCodeDirFor(mode InstallMode) (string, error) {
+	if mode == InstallModeProject {
 		// Project-level: .claude/ in current directory
 		cwd, err := os.Getwd()
 		if err != nil {
@@ -41,6 +50,50 @@ func GetClaudeCodeDir() (string, error) {
 	return filepath.Join(home, ".claude"), nil
 }
 
+// LoadState loads the state file owned by the .claude/ directory of an install mode
+func LoadState(mode InstallMode) (*state.State, error) {
+	claudeDir, err := GetClaudeCodeDirFor(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := state.Load(claudeDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+	return st, nil
+}
+
+// lockState takes the state lock of an install mode's .claude/ directory and loads its state
+// The lock is held until the returned unlock function is called, so concurrent
+// cc-foundry processes cannot interleave their load-modify-save cycles
+func lockState(mode InstallMode) (*state.State, func(), error) {
+	claudeDir, err := GetClaudeCodeDirFor(mode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A project's state is per-checkout, so keep it out of version control from the start
+	if _, err := os.Stat(state.GetStateFilePath(claudeDir)); mode == InstallModeProject && os.IsNotExist(err) {
+		if err := state.WriteGitignore(claudeDir); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	lock, err := state.Lock(claudeDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	st, err := state.Load(claudeDir)
+	if err != nil {
+		lock.Unlock()
+		return nil, nil, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	return st, func() { lock.Unlock() }, nil
+}
+
 // GetTypeDir returns the full path to a specific type directory (commands, agents, skills)
 func GetTypeDir(fileType string) (string, error) {
 	baseDir, err := GetClaudeCodeDir()
@@ -67,51 +120,92 @@ func EnsureDirectoriesExist() error {
 	return nil
 }
 
-// GenerateInstalledFilename creates the ccf-prefixed filename
-func GenerateInstalledFilename(category, filename string) string {
-	// Remove .md extension
-	base := strings.TrimSuffix(filename, ".md")
+// GenerateInstalledFilename creates the filename of a catalog file under the naming policy
+// By default: ccf-[category]-[filename].md
+func GenerateInstalledFilename(source, category, filename string) string {
+	return Naming.Name(source, category, filename) + ".md"
+}
+
+// InstalledPath returns where the naming policy installs a catalog file under a .claude directory
+// Skills: <baseDir>/skills/ccf-[category]-[name]/SKILL.md, others: <baseDir>/<type>/ccf-[category]-[name].md
+// Installed files are found through the state, since they keep the name they were installed under
+func InstalledPath(baseDir, source, category, fileType, filename string) string {
+	return namedPath(Naming, baseDir, source, category, fileType, filename)
+}
 
-	// Generate: ccf-[category]-[filename].md
-	return fmt.Sprintf("ccf-%s-%s.md", category, base)
+// InstallFile stages the installation of a single file in a transaction
+func InstallFile(file embedpkg.CategoryFile, tx *Transaction) error {
+	return installFileAs(file, tx, nil)
 }
 
-// InstallFile installs a single file
-func InstallFile(file embedpkg.CategoryFile, st *state.State) error {
+// installDirect is InstallFile for a file the user asked for outside any profile
+// The file no longer belongs to the profiles that brought it in, so removing them keeps it
+func installDirect(file embedpkg.CategoryFile, tx *Transaction) error {
+	if err := InstallFile(file, tx); err != nil {
+		return err
+	}
+	tx.State().ClearProfiles(installedPathOf(tx.State(), file))
+	return nil
+}
+
+// installPlanned installs planned files one by one with install and returns the ones it installed
+// Files whose downgrade was refused are left out and returned as refused; any other error stops the install
+func installPlanned(files []embedpkg.CategoryFile, tx *Transaction, install func(embedpkg.CategoryFile, *Transaction) error) (installed, refused []embedpkg.CategoryFile, err error) {
+	for _, file := range files {
+		err := install(file, tx)
+		switch {
+		case errors.Is(err, ErrDowngradeRefused):
+			refused = append(refused, file)
+		case err != nil:
+			return nil, nil, err
+		default:
+			installed = append(installed, file)
+		}
+	}
+	return installed, refused, nil
+}
+
+// refusedError reports the files an install left at their newer installed version, or nil when there are none
+func refusedError(refused []embedpkg.CategoryFile) error {
+	if len(refused) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d files not installed, the installed version is newer (use --force to downgrade): %w", len(refused), ErrDowngradeRefused)
+}
+
+// installFileAs is InstallFile for a file that is not in the state but was installed as previous,
+// whose name it keeps; previous is nil for ordinary installs
+func installFileAs(file embedpkg.CategoryFile, tx *Transaction, previous *state.Installation) error {
+	st := tx.State()
+
 	// Ensure directories exist
 	if err := EnsureDirectoriesExist(); err != nil {
 		return err
 	}
 
-	// Get target directory
-	typeDir, err := GetTypeDir(file.Type)
+	// Generate installed path based on type
+	baseDir, err := GetClaudeCodeDir()
 	if err != nil {
 		return err
 	}
 
-	// Generate installed filename/path based on type
-	var installedPath, displayPath, installedFilename string
-
-	if file.Type == "skills" {
-		// Skills: subdirectory with SKILL.md
-		skillName := GenerateInstalledFilename(file.Category, file.Filename)
-		skillName = strings.TrimSuffix(skillName, ".md") // Remove .md extension
-		skillDir := filepath.Join(typeDir, skillName)
-		installedPath = filepath.Join(skillDir, "SKILL.md")
-		installedFilename = filepath.Join(skillName, "SKILL.md")
-
-		// Create skill subdirectory
-		if err := os.MkdirAll(skillDir, 0755); err != nil {
-			return fmt.Errorf("failed to create skill directory %s: %w", skillDir, err)
-		}
-	} else {
-		// Commands and agents: flat .md files
-		installedFilename = GenerateInstalledFilename(file.Category, file.Filename)
-		installedPath = filepath.Join(typeDir, installedFilename)
+	// An installed file keeps its name; a new one is named by the naming policy
+	existing := findInstalled(st, file.Source, file.Category, file.Type, file.Filename)
+	installedPath := InstalledPath(baseDir, file.Source, file.Category, file.Type, file.Filename)
+	namePrefix := Naming.NamePrefix()
+	named := existing
+	if named == nil {
+		named = previous
+	}
+	if named != nil {
+		installedPath, namePrefix = named.InstalledPath, named.NamePrefix
+	} else if err := checkNameCollision(st, file, installedPath); err != nil {
+		return err
 	}
+	installedFilename, _ := filepath.Rel(filepath.Join(baseDir, file.Type), installedPath)
 
 	// Format display path (replace home with ~)
-	displayPath = installedPath
+	displayPath := installedPath
 	if home, err := os.UserHomeDir(); err == nil {
 		displayPath = strings.Replace(installedPath, home, "~", 1)
 	}
@@ -119,39 +213,127 @@ func InstallFile(file embedpkg.CategoryFile, st *state.State) error {
 	// Determine type label (singular form)
 	typeLabel := strings.TrimSuffix(file.Type, "s") // "agents" -> "agent"
 
-	// Check if already installed
-	existing := st.FindInstallation(installedPath)
+	// Templates are rendered with the project's variables; everything else installs as is
+	rendered, err := installContent(file)
+	if err != nil {
+		return err
+	}
+
 	isUpdate := false
+	content := rendered
+	note := ""
+	transition := "" // " v3 → v4" when both versions are known
 
 	if existing != nil {
 		// File already installed, check if content changed
-		if !existing.HasContentChanged(file.Content) {
+		if !packageChanged(existing, file) {
+			// Backfill the merge base for installs made before originals were kept
+			if err := st.StoreOriginal(file.Content); err != nil {
+				return err
+			}
+			restored, err := restoreMissingAssets(existing, file, tx)
+			if err != nil {
+				return err
+			}
+			if restored > 0 {
+				fmt.Printf("  ✓ %s: %s → %s (unchanged, restored %d missing files)\n", typeLabel, installedFilename, displayPath, restored)
+				return nil
+			}
 			fmt.Printf("  ✓ %s: %s → %s (unchanged)\n", typeLabel, installedFilename, displayPath)
 			return nil
 		}
 
-		fmt.Printf("  ⚠ %s: %s → %s (updating)\n", typeLabel, installedFilename, displayPath)
+		// Refuse to replace a newer installed version unless forced
+		installed := installedVersion(existing, baseDir)
+		if isDowngrade(installed, file) && !AllowDowngrade {
+			fmt.Printf("  ⚠ %s: %s → %s (%s installed, catalog has %s; downgrade refused, use --force)\n",
+				typeLabel, installedFilename, displayPath, embedpkg.VersionLabel(installed), file.Metadata.VersionLabel())
+			return ErrDowngradeRefused
+		}
+
+		if transition = versionTransition(installed, file.Metadata.Version); transition != "" {
+			transition = " " + transition
+		}
+		fmt.Printf("  ⚠ %s: %s → %s (updating%s)\n", typeLabel, installedFilename, displayPath, transition)
 		isUpdate = true
+
+		// Don't silently overwrite edits made to the installed copy
+		if existing.HasContentChanged(file.Content) {
+			content, note, err = resolveUpdate(existing, baseDir, installedFilename, rendered)
+			if err != nil {
+				return err
+			}
+		} else {
+			content = nil // only supporting files changed
+			if previous, err := existing.LoadInstalled(baseDir); err == nil {
+				rendered = previous // the installed file keeps the variables it was rendered with
+			}
+		}
+	}
+
+	// Stage file (skills get their subdirectory created here)
+	// A nil content keeps the user's edited file in place
+	if content != nil {
+		if err := tx.Write(installedPath, content); err != nil {
+			return err
+		}
 	}
 
-	// Write file
-	if err := os.WriteFile(installedPath, file.Content, 0644); err != nil {
-		return fmt.Errorf("failed to write file %s: %w", installedPath, err)
+	// Stage the supporting files of a skill package next to SKILL.md
+	kept, err := stageAssets(existing, installedPath, file, tx)
+	if err != nil {
+		return err
+	}
+	if kept > 0 {
+		if note != "" {
+			note += "; "
+		}
+		note += fmt.Sprintf("kept local changes to %d supporting files", kept)
 	}
 
 	// Update state
-	st.RemoveInstallation(installedPath) // Remove old entry if exists
-	st.AddInstallation(file.Category, file.Type, file.Filename, installedPath, file.Content)
+	var profiles []string
+	if existing != nil {
+		profiles = existing.Profiles // an update keeps the profiles that brought the file in
+	}
+	if err := recordInstallation(st, file, installedPath, rendered, namePrefix, profiles); err != nil {
+		return err
+	}
 
 	// Show success with type and path
-	if isUpdate {
-		fmt.Printf("  ✓ %s: %s → %s (updated)\n", typeLabel, installedFilename, displayPath)
-	} else {
+	switch {
+	case note != "":
+		fmt.Printf("  ✓ %s: %s → %s (%s)\n", typeLabel, installedFilename, displayPath, note)
+	case isUpdate:
+		fmt.Printf("  ✓ %s: %s → %s (updated%s)\n", typeLabel, installedFilename, displayPath, transition)
+	case len(file.Assets) > 0:
+		fmt.Printf("  ✓ %s: %s → %s (with %d supporting files)\n", typeLabel, installedFilename, displayPath, len(file.Assets))
+	default:
 		fmt.Printf("  ✓ %s: %s → %s\n", typeLabel, installedFilename, displayPath)
 	}
 	return nil
 }
 
+// recordInstallation records a catalog file as installed at installedPath, replacing any previous entry
+// The catalog content is recorded as installed so it becomes the next merge base
+func recordInstallation(st *state.State, file embedpkg.CategoryFile, installedPath string, rendered []byte, namePrefix string, profiles []string) error {
+	if err := st.StoreOriginal(file.Content); err != nil {
+		return err
+	}
+	if err := st.StoreOriginal(rendered); err != nil {
+		return err
+	}
+	st.RemoveInstallation(installedPath) // Remove old entry if exists
+	st.AddInstallation(file.Category, file.Type, file.Filename, installedPath, file.Content, file.Metadata.Version)
+	st.SetAssets(installedPath, file.Assets)
+	st.SetRendered(installedPath, rendered)
+	st.SetNaming(installedPath, file.Source, namePrefix)
+	for _, profile := range profiles {
+		st.AddProfile(installedPath, profile)
+	}
+	return nil
+}
+
 // InstallCategory installs all files in a category
 func InstallCategory(category string) error {
 	var files []embedpkg.CategoryFile
@@ -173,28 +355,35 @@ func InstallCategory(category string) error {
 		return fmt.Errorf("no files found in category '%s'", category)
 	}
 
-	st, err := state.Load()
+	st, unlock, err := lockState(CurrentInstallMode)
 	if err != nil {
-		return fmt.Errorf("failed to load state: %w", err)
+		return err
 	}
+	defer unlock()
 
-	// Clear screen and show banner
-	fmt.Print("\033[H\033[2J") // Clear screen
-	fmt.Println(bannerStyle.Render(banner))
+	plan, err := PlanInstall(st, files)
+	if err != nil {
+		return err
+	}
+	files = plan.Files
+
+	ShowBanner()
 	if category == "" {
 		fmt.Printf("Installing all categories [%s]\n", GetInstallModeDescription())
 	} else {
 		fmt.Printf("Installing category: %s [%s]\n", category, GetInstallModeDescription())
 	}
 
-	for _, file := range files {
-		if err := InstallFile(file, st); err != nil {
-			return err
-		}
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+
+	files, refused, err := installPlanned(files, tx, installDirect)
+	if err != nil {
+		return err
 	}
 
-	if err := st.Save(); err != nil {
-		return fmt.Errorf("failed to save state: %w", err)
+	if err := commitChanges(tx, files, nil); err != nil {
+		return err
 	}
 
 	if category == "" {
@@ -202,7 +391,7 @@ func InstallCategory(category string) error {
 	} else {
 		fmt.Printf("\n✓ Successfully installed %d files from category '%s' [%s]\n", len(files), category, GetInstallModeDescription())
 	}
-	return nil
+	return refusedError(refused)
 }
 
 // InstallType installs all files of a specific type in a category
@@ -216,79 +405,129 @@ func InstallType(category, fileType string) error {
 		return fmt.Errorf("no %s found in category '%s'", fileType, category)
 	}
 
-	st, err := state.Load()
+	st, unlock, err := lockState(CurrentInstallMode)
 	if err != nil {
-		return fmt.Errorf("failed to load state: %w", err)
+		return err
 	}
+	defer unlock()
 
-	// Clear screen and show banner
-	fmt.Print("\033[H\033[2J") // Clear screen
-	fmt.Println(bannerStyle.Render(banner))
+	plan, err := PlanInstall(st, files)
+	if err != nil {
+		return err
+	}
+	files = plan.Files
+
+	ShowBanner()
 	fmt.Printf("Installing %s from category: %s [%s]\n", fileType, category, GetInstallModeDescription())
 
-	for _, file := range files {
-		if err := InstallFile(file, st); err != nil {
-			return err
-		}
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+
+	files, refused, err := installPlanned(files, tx, installDirect)
+	if err != nil {
+		return err
 	}
 
-	if err := st.Save(); err != nil {
-		return fmt.Errorf("failed to save state: %w", err)
+	if err := commitChanges(tx, files, nil); err != nil {
+		return err
 	}
 
 	fmt.Printf("\n✓ Successfully installed %d %s from category '%s' [%s]\n", len(files), fileType, category, GetInstallModeDescription())
-	return nil
+	return refusedError(refused)
 }
 
-// GetInstallModeDescription returns a human-readable description of the current install mode
-func GetInstallModeDescription() string {
-	if CurrentInstallMode == InstallModeProject {
-		return "project (.claude/)"
+// InstallSingleFile installs one file from a category
+func InstallSingleFile(category, fileType, filename string) error {
+	file, err := embedpkg.GetFile(category, fileType, filename)
+	if err != nil {
+		return fmt.Errorf("file '%s/%s/%s' not found", category, fileType, filename)
 	}
-	return "user (~/.claude/)"
-}
 
-// ShowBanner displays the application banner with screen clear
-func ShowBanner() {
-	fmt.Print("\033[H\033[2J") // Clear screen
-	fmt.Println(bannerStyle.Render(banner))
-}
+	st, unlock, err := lockState(CurrentInstallMode)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-// pathMatchesInstallMode checks if an installation path matches the current install mode
-func pathMatchesInstallMode(installPath string) bool {
-	home, err := os.UserHomeDir()
+	plan, err := PlanInstall(st, []embedpkg.CategoryFile{*file})
 	if err != nil {
-		return false
+		return err
 	}
 
-	userClaudePath := filepath.Join(home, ".claude")
-	cwd, err := os.Getwd()
+	ShowBanner()
+	fmt.Printf("Installing %s/%s/%s [%s]\n", category, fileType, filename, GetInstallModeDescription())
+
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+
+	installed, refused, err := installPlanned(plan.Files, tx, installDirect)
 	if err != nil {
-		return false
+		return err
 	}
-	projectClaudePath := filepath.Join(cwd, ".claude")
 
-	if CurrentInstallMode == InstallModeUser {
-		// User mode: path must be under ~/.claude/
-		return strings.HasPrefix(installPath, userClaudePath)
+	if err := commitChanges(tx, installed, nil); err != nil {
+		return err
 	}
 
-	// Project mode: path must be under current-dir/.claude/
-	return strings.HasPrefix(installPath, projectClaudePath)
+	if len(refused) == 0 {
+		fmt.Printf("\n✓ Successfully installed %s from category '%s' [%s]\n", filename, category, GetInstallModeDescription())
+	}
+	return refusedError(refused)
 }
 
-// ListInstallationsForCurrentMode filters installations by current install mode
-func ListInstallationsForCurrentMode(st *state.State, category, fileType string) []state.Installation {
-	allInstallations := st.ListInstallations(category, fileType)
-	var filtered []state.Installation
+// InstallFiles installs an arbitrary list of catalog files (e.g. picked individually)
+func InstallFiles(files []embedpkg.CategoryFile) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no files selected")
+	}
 
-	for _, inst := range allInstallations {
-		if pathMatchesInstallMode(inst.InstalledPath) {
-			filtered = append(filtered, inst)
-		}
+	st, unlock, err := lockState(CurrentInstallMode)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	plan, err := PlanInstall(st, files)
+	if err != nil {
+		return err
 	}
+	files = plan.Files
+
+	ShowBanner()
+	fmt.Printf("Installing %d selected files [%s]\n", len(files), GetInstallModeDescription())
 
-	return filtered
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+
+	files, refused, err := installPlanned(files, tx, installDirect)
+	if err != nil {
+		return err
+	}
+
+	if err := commitChanges(tx, files, nil); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✓ Successfully installed %d selected files [%s]\n", len(files), GetInstallModeDescription())
+	return refusedError(refused)
+}
+
+// GetInstallModeDescription returns a human-readable description of the current install mode
+func GetInstallModeDescription() string {
+	if CurrentInstallMode == InstallModeProject {
+		return "project (.claude/)"
+	}
+	return "user (~/.claude/)"
+}
+
+// ShowBanner displays the application banner with screen clear
+// In headless mode nothing is printed so output stays script-friendly
+func ShowBanner() {
+	if Headless {
+		return
+	}
+	fmt.Print("\033[H\033[2J") // Clear screen
+	fmt.Println(bannerStyle.Render(banner))
 }
 
 // LocationAvailability indicates which locations have files for a category
@@ -301,36 +540,42 @@ type LocationAvailability struct {
 
 // CheckLocationAvailability checks which locations have files for a category
 func CheckLocationAvailability(category, fileType string) (LocationAvailability, error) {
-	st, err := state.Load()
+	userState, err := LoadState(InstallModeUser)
 	if err != nil {
-		return LocationAvailability{}, fmt.Errorf("failed to load state: %w", err)
+		return LocationAvailability{}, err
 	}
 
-	home, err := os.UserHomeDir()
+	projectState, err := LoadState(InstallModeProject)
 	if err != nil {
-		return LocationAvailability{}, fmt.Errorf("failed to get home directory: %w", err)
+		return LocationAvailability{}, err
 	}
 
-	cwd, err := os.Getwd()
+	var result LocationAvailability
+	result.UserCount = len(userState.ListInstallations(category, fileType))
+	result.ProjectCount = len(projectState.ListInstallations(category, fileType))
+	result.HasUserLevel = result.UserCount > 0
+	result.HasProjectLevel = result.ProjectCount > 0
+
+	return result, nil
+}
+
+// CheckProfileLocationAvailability checks which locations have files a profile brought in
+func CheckProfileLocationAvailability(name string) (LocationAvailability, error) {
+	userState, err := LoadState(InstallModeUser)
 	if err != nil {
-		return LocationAvailability{}, fmt.Errorf("failed to get working directory: %w", err)
+		return LocationAvailability{}, err
 	}
 
-	allInstallations := st.ListInstallations(category, fileType)
+	projectState, err := LoadState(InstallModeProject)
+	if err != nil {
+		return LocationAvailability{}, err
+	}
 
 	var result LocationAvailability
-	userClaudePath := filepath.Join(home, ".claude")
-	projectClaudePath := filepath.Join(cwd, ".claude")
-
-	for _, inst := range allInstallations {
-		if strings.HasPrefix(inst.InstalledPath, userClaudePath) {
-			result.HasUserLevel = true
-			result.UserCount++
-		} else if strings.HasPrefix(inst.InstalledPath, projectClaudePath) {
-			result.HasProjectLevel = true
-			result.ProjectCount++
-		}
-	}
+	result.UserCount = len(userState.ListProfileInstallations(name))
+	result.ProjectCount = len(projectState.ListProfileInstallations(name))
+	result.HasUserLevel = result.UserCount > 0
+	result.HasProjectLevel = result.ProjectCount > 0
 
 	return result, nil
 }
@@ -357,23 +602,18 @@ func InstallAll() error {
 	return nil
 }
 
-// RemoveInstallation removes a single installed file
-func RemoveInstallation(installation state.Installation) error {
+// RemoveInstallation stages the removal of a single installed file in a transaction
+func RemoveInstallation(installation state.Installation, tx *Transaction) {
 	// For skills, remove the entire subdirectory
 	if installation.Type == "skills" {
 		// Path is like: ~/.claude/skills/ccf-development-oss-project-setup/SKILL.md
 		// We want to remove: ~/.claude/skills/ccf-development-oss-project-setup/
-		skillDir := filepath.Dir(installation.InstalledPath)
-
-		if err := os.RemoveAll(skillDir); err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("failed to remove skill directory %s: %w", skillDir, err)
-		}
+		tx.Remove(filepath.Dir(installation.InstalledPath))
 	} else {
 		// For commands and agents, just remove the file
-		if err := os.Remove(installation.InstalledPath); err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("failed to remove file %s: %w", installation.InstalledPath, err)
-		}
+		tx.Remove(installation.InstalledPath)
 	}
+	tx.State().RemoveInstallation(installation.InstalledPath)
 
 	// Format display path (replace home with ~)
 	displayPath := installation.InstalledPath
@@ -385,40 +625,39 @@ func RemoveInstallation(installation state.Installation) error {
 	typeLabel := strings.TrimSuffix(installation.Type, "s")
 
 	fmt.Printf("  ✓ %s: %s (removed from %s)\n", typeLabel, filepath.Base(installation.InstalledPath), displayPath)
-	return nil
 }
 
 // RemoveCategory removes all files from a category
 func RemoveCategory(category string) error {
-	st, err := state.Load()
+	st, unlock, err := lockState(CurrentInstallMode)
 	if err != nil {
-		return fmt.Errorf("failed to load state: %w", err)
+		return err
 	}
+	defer unlock()
 
-	installations := ListInstallationsForCurrentMode(st, category, "")
+	installations := st.ListInstallations(category, "")
 	if len(installations) == 0 {
 		// No files to remove - skip silently
 		return nil
 	}
 
-	// Clear screen and show banner
-	fmt.Print("\033[H\033[2J") // Clear screen
-	fmt.Println(bannerStyle.Render(banner))
+	ShowBanner()
 	if category == "" {
 		fmt.Printf("Removing %d files from all categories [%s]\n", len(installations), GetInstallModeDescription())
 	} else {
 		fmt.Printf("Removing %d files from category: %s [%s]\n", len(installations), category, GetInstallModeDescription())
 	}
+	warnBrokenDependents(st, installations)
+
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
 
 	for _, inst := range installations {
-		if err := RemoveInstallation(inst); err != nil {
-			return err
-		}
-		st.RemoveInstallation(inst.InstalledPath)
+		RemoveInstallation(inst, tx)
 	}
 
-	if err := st.Save(); err != nil {
-		return fmt.Errorf("failed to save state: %w", err)
+	if err := commitChanges(tx, nil, installations); err != nil {
+		return err
 	}
 
 	if category == "" {
@@ -431,31 +670,31 @@ func RemoveCategory(category string) error {
 
 // RemoveType removes all files of a specific type from a category
 func RemoveType(category, fileType string) error {
-	st, err := state.Load()
+	st, unlock, err := lockState(CurrentInstallMode)
 	if err != nil {
-		return fmt.Errorf("failed to load state: %w", err)
+		return err
 	}
+	defer unlock()
 
-	installations := ListInstallationsForCurrentMode(st, category, fileType)
+	installations := st.ListInstallations(category, fileType)
 	if len(installations) == 0 {
 		// No files to remove - skip silently
 		return nil
 	}
 
-	// Clear screen and show banner
-	fmt.Print("\033[H\033[2J") // Clear screen
-	fmt.Println(bannerStyle.Render(banner))
+	ShowBanner()
 	fmt.Printf("Removing %d %s from category: %s [%s]\n", len(installations), fileType, category, GetInstallModeDescription())
+	warnBrokenDependents(st, installations)
+
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
 
 	for _, inst := range installations {
-		if err := RemoveInstallation(inst); err != nil {
-			return err
-		}
-		st.RemoveInstallation(inst.InstalledPath)
+		RemoveInstallation(inst, tx)
 	}
 
-	if err := st.Save(); err != nil {
-		return fmt.Errorf("failed to save state: %w", err)
+	if err := commitChanges(tx, nil, installations); err != nil {
+		return err
 	}
 
 	fmt.Printf("\n✓ Successfully removed %d %s from category '%s' [%s]\n", len(installations), fileType, category, GetInstallModeDescription())
@@ -464,16 +703,15 @@ func RemoveType(category, fileType string) error {
 
 // RemoveAll removes all installed files
 func RemoveAll() error {
-	st, err := state.Load()
+	st, unlock, err := lockState(CurrentInstallMode)
 	if err != nil {
-		return fmt.Errorf("failed to load state: %w", err)
+		return err
 	}
+	defer unlock()
 
-	installations := ListInstallationsForCurrentMode(st, "", "")
+	installations := st.ListInstallations("", "")
 
-	// Clear screen and show banner
-	fmt.Print("\033[H\033[2J") // Clear screen
-	fmt.Println(bannerStyle.Render(banner))
+	ShowBanner()
 
 	if len(installations) == 0 {
 		fmt.Println("No files installed by foundry")
@@ -482,18 +720,90 @@ func RemoveAll() error {
 
 	fmt.Printf("Removing all %d installed files [%s]\n", len(installations), GetInstallModeDescription())
 
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+
 	for _, inst := range installations {
-		if err := RemoveInstallation(inst); err != nil {
-			// Log error but continue
-			fmt.Printf("  ⚠ Error removing %s: %v\n", filepath.Base(inst.InstalledPath), err)
-		}
-		st.RemoveInstallation(inst.InstalledPath)
+		RemoveInstallation(inst, tx)
 	}
 
-	if err := st.Save(); err != nil {
-		return fmt.Errorf("failed to save state: %w", err)
+	if err := commitChanges(tx, nil, installations); err != nil {
+		return err
 	}
 
 	fmt.Printf("\n✓ Successfully removed all installed files [%s]\n", GetInstallModeDescription())
 	return nil
 }
+
+// RemoveSingleFile removes one installed file from a category
+func RemoveSingleFile(category, fileType, filename string) error {
+	st, unlock, err := lockState(CurrentInstallMode)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	installations := filterInstallationsByFile(st.ListInstallations(category, fileType), filename)
+	if len(installations) == 0 {
+		// No files to remove - skip silently
+		return nil
+	}
+
+	ShowBanner()
+	fmt.Printf("Removing %s/%s/%s [%s]\n", category, fileType, filename, GetInstallModeDescription())
+	warnBrokenDependents(st, installations)
+
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+
+	for _, inst := range installations {
+		RemoveInstallation(inst, tx)
+	}
+
+	if err := commitChanges(tx, nil, installations); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✓ Successfully removed %s from category '%s' [%s]\n", filename, category, GetInstallModeDescription())
+	return nil
+}
+
+// RemoveFiles removes an arbitrary list of installed files (e.g. picked individually)
+// The list may have been loaded before the state was locked, so only the files still
+// installed are removed, as they are recorded now
+func RemoveFiles(installations []state.Installation) error {
+	st, unlock, err := lockState(CurrentInstallMode)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var current []state.Installation
+	for _, inst := range installations {
+		if installed := st.FindInstallation(inst.InstalledPath); installed != nil {
+			current = append(current, *installed)
+		}
+	}
+	installations = current
+	if len(installations) == 0 {
+		return nil
+	}
+
+	ShowBanner()
+	fmt.Printf("Removing %d selected files [%s]\n", len(installations), GetInstallModeDescription())
+	warnBrokenDependents(st, installations)
+
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+
+	for _, inst := range installations {
+		RemoveInstallation(inst, tx)
+	}
+
+	if err := commitChanges(tx, nil, installations); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✓ Successfully removed %d selected files [%s]\n", len(installations), GetInstallModeDescription())
+	return nil
+}
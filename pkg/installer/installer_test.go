@@ -1,9 +1,22 @@
 package installer
 
 import (
+	"bytes"
+	"errors"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+
+	"testing/fstest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/lockfile"
+	"github.com/shapestone/cc-foundry/pkg/state"
 )
 
 // TestGetInstallModeDescription tests the install mode description strings
@@ -134,6 +147,8 @@ func TestGetTypeDir(t *testing.T) {
 func TestGenerateInstalledFilename(t *testing.T) {
 	tests := []struct {
 		name     string
+		naming   embedpkg.NamingConfig
+		source   string
 		category string
 		filename string
 		expected string
@@ -156,14 +171,47 @@ func TestGenerateInstalledFilename(t *testing.T) {
 			filename: "makefile-guide.md",
 			expected: "ccf-development-makefile-guide.md",
 		},
+		{
+			name:     "source without qualifier",
+			source:   "team",
+			category: "development",
+			filename: "deploy.md",
+			expected: "ccf-development-deploy.md",
+		},
+		{
+			name:     "custom prefix and separator",
+			naming:   embedpkg.NamingConfig{Prefix: "acme", Separator: "_"},
+			category: "development",
+			filename: "deploy.md",
+			expected: "acme_development_deploy.md",
+		},
+		{
+			name:     "source qualifier",
+			naming:   embedpkg.NamingConfig{Prefix: "acme", QualifySource: true},
+			source:   "team",
+			category: "development",
+			filename: "deploy.md",
+			expected: "acme-team-development-deploy.md",
+		},
+		{
+			name:     "built-in file with source qualifier",
+			naming:   embedpkg.NamingConfig{QualifySource: true},
+			category: "development",
+			filename: "deploy.md",
+			expected: "ccf-development-deploy.md",
+		},
 	}
 
+	originalNaming := Naming
+	defer func() { Naming = originalNaming }()
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := GenerateInstalledFilename(tt.category, tt.filename)
+			Naming = tt.naming
+			result := GenerateInstalledFilename(tt.source, tt.category, tt.filename)
 			if result != tt.expected {
-				t.Errorf("GenerateInstalledFilename(%q, %q) = %q, want %q",
-					tt.category, tt.filename, result, tt.expected)
+				t.Errorf("GenerateInstalledFilename(%q, %q, %q) = %q, want %q",
+					tt.source, tt.category, tt.filename, result, tt.expected)
 			}
 		})
 	}
@@ -297,3 +345,1222 @@ func TestCommandAgentFlatStructure(t *testing.T) {
 		})
 	}
 }
+
+// TestParseSelector tests parsing of <category>[/<type>[/<file>]] selectors
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Selector
+		wantErr  bool
+	}{
+		{
+			name:     "all keyword",
+			input:    "all",
+			expected: Selector{},
+		},
+		{
+			name:     "category only",
+			input:    "development",
+			expected: Selector{Category: "development"},
+		},
+		{
+			name:     "category and type",
+			input:    "development/skills",
+			expected: Selector{Category: "development", Type: "skills"},
+		},
+		{
+			name:     "single file without extension",
+			input:    "development/skills/project-layout-go",
+			expected: Selector{Category: "development", Type: "skills", File: "project-layout-go.md"},
+		},
+		{
+			name:     "single file with extension",
+			input:    "oss-development/agents/oss-auditor.md",
+			expected: Selector{Category: "oss-development", Type: "agents", File: "oss-auditor.md"},
+		},
+		{
+			name:     "profile",
+			input:    "profile:go-backend",
+			expected: Selector{Profile: "go-backend"},
+		},
+		{
+			name:    "profile without a name",
+			input:   "profile:",
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			input:   "development/widgets",
+			wantErr: true,
+		},
+		{
+			name:    "empty segment",
+			input:   "development//file",
+			wantErr: true,
+		},
+		{
+			name:    "too many segments",
+			input:   "a/skills/b/c",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseSelector(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseSelector(%q) expected error, got %+v", tt.input, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSelector(%q) error = %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("ParseSelector(%q) = %+v, want %+v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRemoveSelection tests that removing a selector removes exactly the installed files it matches
+// and never touches files cc-foundry does not manage
+func TestRemoveSelection(t *testing.T) {
+	catalog := fstest.MapFS{
+		"categories/development/commands/deploy.md": {Data: []byte("---\nname: deploy\n---\n")},
+		"categories/development/skills/layout.md":   {Data: []byte("---\nname: layout\n---\n")},
+		"categories/development/skills/make.md":     {Data: []byte("---\nname: make\n---\n")},
+		"categories/oss/agents/auditor.md":          {Data: []byte("---\nname: auditor\n---\n")},
+	}
+	installed := map[string]string{
+		"development/commands/deploy": filepath.Join("commands", "ccf-development-deploy.md"),
+		"development/skills/layout":   filepath.Join("skills", "ccf-development-layout", "SKILL.md"),
+		"development/skills/make":     filepath.Join("skills", "ccf-development-make", "SKILL.md"),
+		"oss/agents/auditor":          filepath.Join("agents", "ccf-oss-auditor.md"),
+	}
+	unmanaged := []string{
+		filepath.Join("commands", "ccf-development-manual.md"), // foundry name, not in state
+		filepath.Join("commands", "mine.md"),
+		filepath.Join("skills", "mine", "SKILL.md"),
+	}
+
+	tests := []struct {
+		selector string
+		removed  []string
+	}{
+		{"all", []string{"development/commands/deploy", "development/skills/layout", "development/skills/make", "oss/agents/auditor"}},
+		{"development", []string{"development/commands/deploy", "development/skills/layout", "development/skills/make"}},
+		{"development/skills", []string{"development/skills/layout", "development/skills/make"}},
+		{"development/skills/layout", []string{"development/skills/layout"}},
+		{"oss/agents/auditor.md", []string{"oss/agents/auditor"}},
+		{"oss/commands", nil},
+		{"development/skills/missing", nil},
+		{"unknown", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			home := setupUserInstall(t, catalog)
+			claudeDir := filepath.Join(home, ".claude")
+
+			if err := InstallAll(); err != nil {
+				t.Fatalf("InstallAll() error = %v", err)
+			}
+			for _, name := range unmanaged {
+				path := filepath.Join(claudeDir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte("mine\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			sel, err := ParseSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("ParseSelector(%q) error = %v", tt.selector, err)
+			}
+			selected, err := ListSelectedInstallations(sel)
+			if err != nil {
+				t.Fatalf("ListSelectedInstallations() error = %v", err)
+			}
+			if len(selected) != len(tt.removed) {
+				t.Errorf("ListSelectedInstallations() matched %d files, want %d", len(selected), len(tt.removed))
+			}
+
+			if err := RemoveSelection(sel); err != nil {
+				t.Fatalf("RemoveSelection() error = %v", err)
+			}
+
+			for ref, name := range installed {
+				_, err := os.Stat(filepath.Join(claudeDir, name))
+				if removed := slices.Contains(tt.removed, ref); removed != os.IsNotExist(err) {
+					t.Errorf("%s: removed = %v, want %v", ref, os.IsNotExist(err), removed)
+				}
+			}
+			for _, name := range unmanaged {
+				if _, err := os.Stat(filepath.Join(claudeDir, name)); err != nil {
+					t.Errorf("unmanaged %s was touched: %v", name, err)
+				}
+			}
+
+			st, err := LoadState(InstallModeUser)
+			if err != nil {
+				t.Fatalf("LoadState() error = %v", err)
+			}
+			if want := len(installed) - len(tt.removed); len(st.Installations) != want {
+				t.Errorf("state has %d installations after removal, want %d", len(st.Installations), want)
+			}
+		})
+	}
+}
+
+// TestRemoveFilesStale tests that removing a list loaded before another change leaves files
+// that are no longer installed alone
+func TestRemoveFilesStale(t *testing.T) {
+	home := setupUserInstall(t, fstest.MapFS{
+		"categories/development/commands/deploy.md": {Data: []byte("---\nname: deploy\n---\n")},
+		"categories/development/commands/build.md":  {Data: []byte("---\nname: build\n---\n")},
+	})
+	if err := InstallType("development", "commands"); err != nil {
+		t.Fatalf("InstallType() error = %v", err)
+	}
+
+	picked, err := ListSelectedInstallations(Selector{Category: "development"})
+	if err != nil || len(picked) != 2 {
+		t.Fatalf("ListSelectedInstallations() = %d files, %v; want 2", len(picked), err)
+	}
+
+	// Meanwhile build is removed and the user puts a file of their own in its place
+	if err := RemoveSingleFile("development", "commands", "build.md"); err != nil {
+		t.Fatalf("RemoveSingleFile() error = %v", err)
+	}
+	build := filepath.Join(home, ".claude", "commands", "ccf-development-build.md")
+	if err := os.WriteFile(build, []byte("mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RemoveFiles(picked); err != nil {
+		t.Fatalf("RemoveFiles() error = %v", err)
+	}
+	if _, err := os.Stat(build); err != nil {
+		t.Errorf("RemoveFiles() removed a file that was no longer installed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".claude", "commands", "ccf-development-deploy.md")); !os.IsNotExist(err) {
+		t.Errorf("RemoveFiles() kept deploy: %v", err)
+	}
+}
+
+// TestMultiSelectModel tests checkbox toggling in the file picker
+func TestMultiSelectModel(t *testing.T) {
+	m := multiSelectModel{
+		menuModel: menuModel{options: []string{"a", "b", "c"}},
+		checked:   make([]bool, 3),
+	}
+
+	press := func(m multiSelectModel, key tea.KeyMsg) multiSelectModel {
+		updated, _ := m.Update(key)
+		return updated.(multiSelectModel)
+	}
+
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	all := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}}
+
+	// Toggle first item, move down, toggle second
+	m = press(m, space)
+	m = press(m, down)
+	m = press(m, space)
+
+	if !m.checked[0] || !m.checked[1] || m.checked[2] {
+		t.Errorf("checked = %v, want [true true false]", m.checked)
+	}
+	if m.selected != 1 {
+		t.Errorf("selected = %d, want 1", m.selected)
+	}
+
+	// "a" checks everything when not all are checked, then clears everything
+	m = press(m, all)
+	if !m.checked[0] || !m.checked[1] || !m.checked[2] {
+		t.Errorf("checked = %v, want all true", m.checked)
+	}
+	m = press(m, all)
+	if m.checked[0] || m.checked[1] || m.checked[2] {
+		t.Errorf("checked = %v, want all false", m.checked)
+	}
+}
+
+// TestGroupedMultiSelectModel tests that toggling a group header toggles its whole group
+func TestGroupedMultiSelectModel(t *testing.T) {
+	m := multiSelectModel{
+		menuModel: menuModel{options: []string{"Group 1", "a", "b", "Group 2", "c"}},
+		checked:   []bool{false, false, true, false, false},
+		headers:   []bool{true, false, false, true, false},
+	}
+
+	press := func(m multiSelectModel, key tea.KeyMsg) multiSelectModel {
+		updated, _ := m.Update(key)
+		return updated.(multiSelectModel)
+	}
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+
+	if view := m.View(); !strings.Contains(view, "[-] Group 1") || !strings.Contains(view, "  [x] b") {
+		t.Errorf("View() = %q, want a partly checked group with its options indented", view)
+	}
+
+	// A partly checked group is checked completely, then cleared
+	m = press(m, space)
+	if want := []bool{false, true, true, false, false}; !slices.Equal(m.checked, want) {
+		t.Errorf("checked = %v, want %v", m.checked, want)
+	}
+	m = press(m, space)
+	if want := []bool{false, false, false, false, false}; !slices.Equal(m.checked, want) {
+		t.Errorf("checked = %v, want %v", m.checked, want)
+	}
+}
+
+// TestTransactionRollback verifies that a failed commit restores every file it touched
+func TestTransactionRollback(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	claudeDir := filepath.Join(home, ".claude")
+	existing := filepath.Join(claudeDir, "commands", "ccf-development-existing.md")
+	removed := filepath.Join(claudeDir, "agents", "ccf-development-removed.md")
+	added := filepath.Join(claudeDir, "skills", "ccf-development-new", "SKILL.md")
+	for path, content := range map[string]string{existing: "old", removed: "keep me"} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st, err := state.Load(claudeDir)
+	if err != nil {
+		t.Fatalf("state.Load() error = %v", err)
+	}
+
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+	if err := tx.Write(existing, []byte("new")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := tx.Write(added, []byte("skill")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	tx.Remove(removed)
+
+	err = tx.Commit(func() error { return errors.New("disk full") })
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("Commit() error = %v, want a rolled back error", err)
+	}
+
+	if content, _ := os.ReadFile(existing); string(content) != "old" {
+		t.Errorf("overwritten file = %q, want restored %q", content, "old")
+	}
+	if content, _ := os.ReadFile(removed); string(content) != "keep me" {
+		t.Errorf("removed file = %q, want restored %q", content, "keep me")
+	}
+	if _, err := os.Stat(filepath.Dir(added)); !os.IsNotExist(err) {
+		t.Errorf("skill directory created by the transaction should be gone")
+	}
+
+	// Neither temp files nor backups are left behind
+	entries, err := os.ReadDir(filepath.Join(claudeDir, "commands"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("commands/ has %d entries, want only the restored file", len(entries))
+	}
+	if backups, _ := os.ReadDir(filepath.Join(home, BackupDirName)); len(backups) != 0 {
+		t.Errorf("backups should be deleted after rollback, found %d", len(backups))
+	}
+}
+
+// TestTransactionCommit verifies that a successful commit applies every staged change
+func TestTransactionCommit(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	claudeDir := filepath.Join(home, ".claude")
+	removed := filepath.Join(claudeDir, "skills", "ccf-development-old")
+	added := filepath.Join(claudeDir, "commands", "ccf-development-new.md")
+	if err := os.MkdirAll(removed, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(removed, "SKILL.md"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := state.Load(claudeDir)
+	if err != nil {
+		t.Fatalf("state.Load() error = %v", err)
+	}
+
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+	if err := tx.Write(added, []byte("new")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	tx.Remove(removed)
+
+	if err := tx.Commit(st.Save); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	if content, _ := os.ReadFile(added); string(content) != "new" {
+		t.Errorf("written file = %q, want %q", content, "new")
+	}
+	if _, err := os.Stat(removed); !os.IsNotExist(err) {
+		t.Errorf("removed skill directory still exists")
+	}
+	if _, err := os.Stat(state.GetStateFilePath(claudeDir)); err != nil {
+		t.Errorf("state should be saved by the commit: %v", err)
+	}
+	if backups, _ := os.ReadDir(filepath.Join(home, BackupDirName)); len(backups) != 0 {
+		t.Errorf("backups should be deleted after commit, found %d", len(backups))
+	}
+}
+
+// TestResolveUpdate verifies how updates treat an installed file with local edits
+func TestResolveUpdate(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	installedPath := filepath.Join(claudeDir, "commands", "ccf-development-test.md")
+	original := []byte("# Title\n\nbody\n\nfooter\n")
+	local := []byte("# Title\n\nbody\n\nmy footer\n")
+	catalog := []byte("# New title\n\nbody\n\nfooter\n")
+
+	st, err := state.Load(claudeDir)
+	if err != nil {
+		t.Fatalf("state.Load() error = %v", err)
+	}
+	if err := st.StoreOriginal(original); err != nil {
+		t.Fatalf("StoreOriginal() error = %v", err)
+	}
+	st.AddInstallation("development", "commands", "test.md", installedPath, original, "")
+	if err := os.MkdirAll(filepath.Dir(installedPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(installedPath, local, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	originalStrategy := CurrentUpdateStrategy
+	originalHeadless := Headless
+	defer func() {
+		CurrentUpdateStrategy = originalStrategy
+		Headless = originalHeadless
+	}()
+	Headless = true
+
+	tests := []struct {
+		strategy UpdateStrategy
+		want     []byte
+	}{
+		{UpdateAsk, nil},
+		{UpdateKeepLocal, nil},
+		{UpdateTakeCatalog, catalog},
+		{UpdateMerge, []byte("# New title\n\nbody\n\nmy footer\n")},
+	}
+
+	for _, tt := range tests {
+		CurrentUpdateStrategy = tt.strategy
+		got, _, err := resolveUpdate(st.FindInstallation(installedPath), claudeDir, "test", catalog)
+		if err != nil {
+			t.Fatalf("resolveUpdate(%d) error = %v", tt.strategy, err)
+		}
+		if string(got) != string(tt.want) || (got == nil) != (tt.want == nil) {
+			t.Errorf("resolveUpdate(%d) = %q, want %q", tt.strategy, got, tt.want)
+		}
+	}
+}
+
+// setupUserInstall points the installer at a temporary home and the catalog fsys for the test,
+// installing into the user scope without the banner; the package settings are restored afterwards
+func setupUserInstall(t *testing.T, fsys fs.FS) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	originalFS := embedpkg.CategoriesFS
+	originalMode := CurrentInstallMode
+	originalHeadless := Headless
+	originalStrategy := CurrentUpdateStrategy
+	originalDowngrade := AllowDowngrade
+	originalNaming := Naming
+	originalSeparate := embedpkg.SeparateSources
+	t.Cleanup(func() {
+		embedpkg.CategoriesFS = originalFS
+		CurrentInstallMode = originalMode
+		Headless = originalHeadless
+		CurrentUpdateStrategy = originalStrategy
+		AllowDowngrade = originalDowngrade
+		Naming = originalNaming
+		embedpkg.SeparateSources = originalSeparate
+	})
+
+	embedpkg.CategoriesFS = fsys
+	CurrentInstallMode = InstallModeUser
+	Headless = true
+	return home
+}
+
+// TestVersionedInstall verifies that upgrades are recorded and listed, and downgrades need --force
+func TestVersionedInstall(t *testing.T) {
+	home := setupUserInstall(t, nil)
+
+	catalogAt := func(version string) []byte {
+		content := []byte("---\nname: layout\ndescription: Layout\nversion: " + version + "\n---\nversion " + version + "\n")
+		embedpkg.CategoriesFS = fstest.MapFS{"categories/development/skills/layout.md": {Data: content}}
+		return content
+	}
+	installedPath := filepath.Join(home, ".claude", "skills", "ccf-development-layout", "SKILL.md")
+	installedVersion := func() string {
+		st, err := LoadState(InstallModeUser)
+		if err != nil {
+			t.Fatalf("LoadState() error = %v", err)
+		}
+		inst := st.FindInstallation(installedPath)
+		if inst == nil {
+			t.Fatalf("layout.md is not installed")
+		}
+		return inst.Version
+	}
+
+	catalogAt("3")
+	if err := InstallSingleFile("development", "skills", "layout.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+	if got := installedVersion(); got != "3" {
+		t.Errorf("installed version = %q, want %q", got, "3")
+	}
+
+	catalogAt("4")
+	outdated, err := ListOutdated()
+	if err != nil {
+		t.Fatalf("ListOutdated() error = %v", err)
+	}
+	if len(outdated) != 1 || outdated[0].Installed != "3" || outdated[0].Latest != "4" {
+		t.Fatalf("ListOutdated() = %+v, want layout.md 3 → 4", outdated)
+	}
+	if err := InstallSingleFile("development", "skills", "layout.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+	if outdated, _ := ListOutdated(); len(outdated) != 0 {
+		t.Errorf("ListOutdated() after update = %+v, want none", outdated)
+	}
+
+	older := catalogAt("3")
+	if err := InstallSingleFile("development", "skills", "layout.md"); !errors.Is(err, ErrDowngradeRefused) {
+		t.Fatalf("InstallSingleFile() error = %v, want ErrDowngradeRefused", err)
+	}
+	if got := installedVersion(); got != "4" {
+		t.Errorf("downgrade without force installed version %q, want %q", got, "4")
+	}
+
+	AllowDowngrade = true
+	if err := InstallSingleFile("development", "skills", "layout.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+	if content, _ := os.ReadFile(installedPath); string(content) != string(older) {
+		t.Errorf("forced downgrade wrote %q, want %q", content, older)
+	}
+}
+
+// TestSkillPackageInstall verifies that every file of a skill package is installed, tracked, updated, and removed
+func TestSkillPackageInstall(t *testing.T) {
+	skill := []byte("---\nname: kit\ndescription: Kit\n---\nRun scripts/run.sh\n")
+	home := setupUserInstall(t, fstest.MapFS{
+		"categories/development/skills/kit/SKILL.md":       {Data: skill},
+		"categories/development/skills/kit/scripts/run.sh": {Data: []byte("#!/bin/sh\necho v1\n")},
+		"categories/development/skills/kit/notes.md":       {Data: []byte("notes v1\n")},
+		"categories/development/skills/kit/old.md":         {Data: []byte("old\n")},
+	})
+
+	skillDir := filepath.Join(home, ".claude", "skills", "ccf-development-kit")
+	if err := InstallSingleFile("development", "skills", "kit.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(skillDir, "scripts", "run.sh"))
+	if err != nil {
+		t.Fatalf("supporting script not installed: %v", err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("script mode = %v, want executable", info.Mode().Perm())
+	}
+	st, err := LoadState(InstallModeUser)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if inst := st.FindInstallation(filepath.Join(skillDir, "SKILL.md")); inst == nil || len(inst.Assets) != 3 {
+		t.Fatalf("state installation = %+v, want 3 tracked supporting files", inst)
+	}
+
+	// Update: the script changes, notes.md was edited locally, and old.md is gone from the package
+	if err := os.WriteFile(filepath.Join(skillDir, "notes.md"), []byte("my notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	embedpkg.CategoriesFS = fstest.MapFS{
+		"categories/development/skills/kit/SKILL.md":       {Data: skill},
+		"categories/development/skills/kit/scripts/run.sh": {Data: []byte("#!/bin/sh\necho v2\n")},
+		"categories/development/skills/kit/notes.md":       {Data: []byte("notes v2\n")},
+	}
+	if err := InstallSingleFile("development", "skills", "kit.md"); err != nil {
+		t.Fatalf("InstallSingleFile() update error = %v", err)
+	}
+
+	for name, want := range map[string]string{"scripts/run.sh": "#!/bin/sh\necho v2\n", "notes.md": "my notes\n"} {
+		if content, _ := os.ReadFile(filepath.Join(skillDir, filepath.FromSlash(name))); string(content) != want {
+			t.Errorf("%s after update = %q, want %q", name, content, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(skillDir, "old.md")); !os.IsNotExist(err) {
+		t.Errorf("old.md should be removed when the package drops it")
+	}
+
+	if err := RemoveSingleFile("development", "skills", "kit.md"); err != nil {
+		t.Fatalf("RemoveSingleFile() error = %v", err)
+	}
+	if _, err := os.Stat(skillDir); !os.IsNotExist(err) {
+		t.Errorf("skill directory should be removed with all its files")
+	}
+}
+
+// TestPlanInstall verifies that required files are installed first and that removing them warns
+func TestPlanInstall(t *testing.T) {
+	setupUserInstall(t, fstest.MapFS{
+		"categories/oss/agents/auditor.md":        {Data: []byte("---\nname: auditor\nrequires: [skills/setup]\nrecommends: [skills/badges]\n---\n")},
+		"categories/oss/skills/setup.md":          {Data: []byte("---\nname: setup\nrequires: [development/skills/layout]\n---\n")},
+		"categories/oss/skills/badges.md":         {Data: []byte("---\nname: badges\n---\n")},
+		"categories/development/skills/layout.md": {Data: []byte("---\nname: layout\n---\n")},
+		"categories/oss/commands/loop.md":         {Data: []byte("---\nname: loop\nrequires: [commands/back]\n---\n")},
+		"categories/oss/commands/back.md":         {Data: []byte("---\nname: back\nrequires: [commands/loop]\n---\n")},
+		"categories/oss/commands/broken.md":       {Data: []byte("---\nname: broken\nrequires: [skills/gone]\n---\n")},
+	})
+
+	st, err := LoadState(InstallModeUser)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	auditor, err := embedpkg.GetFile("oss", "agents", "auditor.md")
+	if err != nil {
+		t.Fatalf("GetFile() error = %v", err)
+	}
+	plan, err := PlanInstall(st, []embedpkg.CategoryFile{*auditor})
+	if err != nil {
+		t.Fatalf("PlanInstall() error = %v", err)
+	}
+	var order []string
+	for _, file := range plan.Files {
+		order = append(order, embedpkg.Reference(file.Category, file.Type, file.Filename))
+	}
+	if want := "development/skills/layout oss/skills/setup oss/agents/auditor"; strings.Join(order, " ") != want {
+		t.Errorf("PlanInstall() order = %v, want %s", order, want)
+	}
+	if got := plan.RequiredBy["oss/skills/setup"]; got != "oss/agents/auditor" {
+		t.Errorf("setup required by %q, want oss/agents/auditor", got)
+	}
+	if len(plan.Recommended) != 1 || plan.Recommended[0] != "oss/skills/badges" {
+		t.Errorf("PlanInstall() recommended = %v, want [oss/skills/badges]", plan.Recommended)
+	}
+
+	for file, want := range map[string]string{"loop.md": "dependency cycle", "broken.md": "not in the catalog"} {
+		cmd, _ := embedpkg.GetFile("oss", "commands", file)
+		if _, err := PlanInstall(st, []embedpkg.CategoryFile{*cmd}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("PlanInstall(%s) error = %v, want %q", file, err, want)
+		}
+	}
+
+	if err := InstallSingleFile("oss", "agents", "auditor.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+	if st, err = LoadState(InstallModeUser); err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if got := len(st.ListInstallations("", "")); got != 3 {
+		t.Errorf("installed %d files, want the agent and its 2 dependencies", got)
+	}
+	broken := BrokenDependents(st, st.ListInstallations("oss", "skills"))
+	if len(broken) != 1 || broken[0] != "oss/agents/auditor requires oss/skills/setup" {
+		t.Errorf("BrokenDependents() = %v", broken)
+	}
+}
+
+// TestProfileInstall verifies that profiles install as a unit, tag the files they bring in,
+// and remove only files no other profile brought in and that were not installed on their own
+func TestProfileInstall(t *testing.T) {
+	home := setupUserInstall(t, fstest.MapFS{
+		"categories/development/skills/layout.md":    {Data: []byte("---\nname: layout\n---\n")},
+		"categories/development/skills/make.md":      {Data: []byte("---\nname: make\n---\n")},
+		"categories/development/skills/hexagonal.md": {Data: []byte("---\nname: hexagonal\n---\n")},
+		"categories/development/skills/tidy.md":      {Data: []byte("---\nname: tidy\n---\n")},
+		"categories/development/profiles/backend.md": {Data: []byte("---\nname: backend\ndescription: Backend\nfiles: [skills/layout, skills/make, skills/hexagonal, skills/tidy]\n---\n")},
+		"categories/oss/agents/auditor.md":           {Data: []byte("---\nname: auditor\n---\n")},
+		"categories/oss/profiles/release.md":         {Data: []byte("---\nname: release\ndescription: Release\nfiles: [agents/auditor, development/skills/make]\n---\n")},
+	})
+
+	skillPath := func(name string) string {
+		return filepath.Join(home, ".claude", "skills", "ccf-development-"+name, "SKILL.md")
+	}
+
+	// layout was installed on its own before the profile and must survive removing it
+	if err := InstallSingleFile("development", "skills", "layout.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+	for _, name := range []string{"backend", "release"} {
+		if err := InstallSelection(Selector{Profile: name}); err != nil {
+			t.Fatalf("InstallSelection(profile %s) error = %v", name, err)
+		}
+	}
+
+	// hexagonal is installed on its own after the profile brought it in, so it must survive too
+	if err := InstallSelection(Selector{Category: "development", Type: "skills", File: "hexagonal.md"}); err != nil {
+		t.Fatalf("InstallSelection(development/skills/hexagonal) error = %v", err)
+	}
+
+	st, err := LoadState(InstallModeUser)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	for _, name := range []string{"layout", "hexagonal"} {
+		if inst := st.FindInstallation(skillPath(name)); inst == nil || len(inst.Profiles) != 0 {
+			t.Errorf("%s installation = %+v, want it installed without a profile", name, inst)
+		}
+	}
+	if inst := st.FindInstallation(skillPath("make")); inst == nil || strings.Join(inst.Profiles, ",") != "backend,release" {
+		t.Errorf("make installation = %+v, want profiles backend and release", inst)
+	}
+
+	if err := RemoveSelection(Selector{Profile: "backend"}); err != nil {
+		t.Fatalf("RemoveSelection(profile backend) error = %v", err)
+	}
+	for name, want := range map[string]bool{"layout": true, "make": true, "hexagonal": true, "tidy": false} {
+		if _, err := os.Stat(skillPath(name)); (err == nil) != want {
+			t.Errorf("%s installed = %v after removing the backend profile, want %v", name, err == nil, want)
+		}
+	}
+	if st, err = LoadState(InstallModeUser); err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if inst := st.FindInstallation(skillPath("make")); inst == nil || strings.Join(inst.Profiles, ",") != "release" {
+		t.Errorf("make installation = %+v, want only the release profile", inst)
+	}
+}
+
+// TestResolveTemplateVars verifies that template variables are read from go.mod, the origin remote, and LICENSE
+func TestResolveTemplateVars(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := filepath.Join(t.TempDir(), "checkout")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"remote", "add", "origin", "git@github.com:acme/widgets.git"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+	files := map[string]string{
+		"go.mod":  "module github.com/acme/widgets\n\ngo 1.25\n",
+		"LICENSE": "                                 Apache License\n                           Version 2.0, January 2004\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := TemplateVars{
+		ProjectName: "widgets",
+		ModulePath:  "github.com/acme/widgets",
+		GitOwner:    "acme",
+		GitRepo:     "widgets",
+		License:     "Apache-2.0",
+	}
+	if got := ResolveTemplateVars(dir); got != want {
+		t.Errorf("ResolveTemplateVars() = %+v, want %+v", got, want)
+	}
+}
+
+// TestTemplateInstall verifies that templates are rendered at project install and checked against the rendered content,
+// and rendered with empty variables at user scope
+func TestTemplateInstall(t *testing.T) {
+	home := setupUserInstall(t, nil)
+
+	project := filepath.Join(t.TempDir(), "widgets")
+	if err := os.Mkdir(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "go.mod"), []byte("module example.com/widgets\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(project)
+
+	template := []byte("---\nname: badges\ntemplate: true\n---\n{{.ProjectName}} {{.ModulePath}} {{or .GitOwner \"OWNER\"}} {{\"{{\"}}\n")
+	embedpkg.CategoriesFS = fstest.MapFS{"categories/oss/skills/badges.md": {Data: template}}
+
+	// A user install is shared by every project, so it is rendered without project values
+	userPath := filepath.Join(home, ".claude", "skills", "ccf-oss-badges", "SKILL.md")
+	if err := InstallSingleFile("oss", "skills", "badges.md"); err != nil {
+		t.Fatalf("InstallSingleFile() in user mode error = %v", err)
+	}
+	userContent, err := os.ReadFile(userPath)
+	if want := "---\nname: badges\ntemplate: true\n---\n  OWNER {{\n"; err != nil || string(userContent) != want {
+		t.Errorf("user install wrote %q, %v; want %q", userContent, err, want)
+	}
+	userState, err := LoadState(InstallModeUser)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if inst := userState.FindInstallation(userPath); inst == nil || inst.HasContentChanged(template) || inst.IsModified(userContent) {
+		t.Errorf("user installation = %+v, want the template recorded with its rendered content", inst)
+	}
+
+	CurrentInstallMode = InstallModeProject
+	installedPath := filepath.Join(project, ".claude", "skills", "ccf-oss-badges", "SKILL.md")
+	for range 2 { // the second install finds the file unchanged
+		if err := InstallSingleFile("oss", "skills", "badges.md"); err != nil {
+			t.Fatalf("InstallSingleFile() error = %v", err)
+		}
+	}
+
+	rendered, err := os.ReadFile(installedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "---\nname: badges\ntemplate: true\n---\nwidgets example.com/widgets OWNER {{\n"; string(rendered) != want {
+		t.Errorf("installed content = %q, want %q", rendered, want)
+	}
+
+	st, err := LoadState(InstallModeProject)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	inst := st.FindInstallation(installedPath)
+	if inst == nil {
+		t.Fatalf("badges.md is not installed")
+	}
+	if inst.HasContentChanged(template) || inst.RenderedHash == "" {
+		t.Errorf("installation = %+v, want the template hash and a rendered hash", inst)
+	}
+	if inst.IsModified(rendered) {
+		t.Errorf("IsModified() = true for the rendered content")
+	}
+	if !inst.IsModified(template) {
+		t.Errorf("IsModified() = false for the unrendered template")
+	}
+	if base, err := inst.LoadInstalled(st.Dir()); err != nil || string(base) != string(rendered) {
+		t.Errorf("LoadInstalled() = %q, %v; want the rendered content", base, err)
+	}
+
+	embedpkg.CategoriesFS = fstest.MapFS{"categories/oss/skills/badges.md": {Data: []byte("---\nname: badges\ntemplate: true\n---\n{{.Missing}}\n")}}
+	if err := InstallSingleFile("oss", "skills", "badges.md"); err == nil {
+		t.Errorf("InstallSingleFile() with an unknown variable succeeded, want an error")
+	}
+}
+
+// TestNamingPolicyInstall verifies that installed files keep their name when the naming policy changes
+// and that a name another file already has is refused
+func TestNamingPolicyInstall(t *testing.T) {
+	catalog := fstest.MapFS{
+		"categories/development/commands/deploy.md": {Data: []byte("---\nname: deploy\n---\nv1\n")},
+		"categories/a-b/commands/c.md":              {Data: []byte("---\nname: c\n---\n")},
+		"categories/a/commands/b-c.md":              {Data: []byte("---\nname: b-c\n---\n")},
+	}
+	home := setupUserInstall(t, catalog)
+	commandPath := func(name string) string {
+		return filepath.Join(home, ".claude", "commands", name)
+	}
+
+	Naming = embedpkg.NamingConfig{Prefix: "acme", Separator: "_"}
+	if err := InstallSingleFile("development", "commands", "deploy.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+
+	// An update under the default policy keeps the name the file was installed under
+	Naming = embedpkg.NamingConfig{}
+	catalog["categories/development/commands/deploy.md"] = &fstest.MapFile{Data: []byte("---\nname: deploy\n---\nv2\n")}
+	if err := InstallSingleFile("development", "commands", "deploy.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+	if content, err := os.ReadFile(commandPath("acme_development_deploy.md")); err != nil || !strings.Contains(string(content), "v2") {
+		t.Errorf("updated file = %q, %v; want v2 under its original name", content, err)
+	}
+	if _, err := os.Stat(commandPath("ccf-development-deploy.md")); err == nil {
+		t.Errorf("update installed a second copy under the new policy's name")
+	}
+
+	st, err := LoadState(InstallModeUser)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	inst := st.FindFile("", "development", "commands", "deploy.md")
+	if inst == nil || inst.NamePrefix != "acme_" || NamePrefixOf(*inst) != "acme_" {
+		t.Errorf("installation = %+v, want name prefix acme_", inst)
+	}
+
+	// ccf-a-b-c.md could be either file
+	if err := InstallSingleFile("a-b", "commands", "c.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+	if err := InstallSingleFile("a", "commands", "b-c.md"); err == nil || !strings.Contains(err.Error(), "already used by a-b/commands/c") {
+		t.Errorf("InstallSingleFile() of a colliding name error = %v, want it refused", err)
+	}
+}
+
+// TestAdopt verifies that copies made by hand are registered without being rewritten
+func TestAdopt(t *testing.T) {
+	layout := []byte("---\nname: layout\n---\nLayout\n")
+	deploy := []byte("---\nname: deploy\n---\nDeploy\n")
+	home := setupUserInstall(t, fstest.MapFS{
+		"categories/development/skills/layout.md":   {Data: layout},
+		"categories/development/commands/deploy.md": {Data: deploy},
+	})
+
+	claudeDir := filepath.Join(home, ".claude")
+	skillPath := filepath.Join(claudeDir, "skills", "ccf-development-layout", "SKILL.md")
+	copyPath := filepath.Join(claudeDir, "commands", "my-deploy.md")
+	edited := append(slices.Clone(layout), "My notes\n"...)
+	for path, content := range map[string][]byte{skillPath: edited, copyPath: deploy} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	adoptable, err := FindAdoptable()
+	if err != nil {
+		t.Fatalf("FindAdoptable() error = %v", err)
+	}
+	if len(adoptable) != 1 || adoptable[0].Path != skillPath || !adoptable[0].Modified {
+		t.Fatalf("FindAdoptable() = %+v, want the edited skill", adoptable)
+	}
+
+	// The skill matches by name and the command, under a name of its own, by content
+	adopted, err := Adopt([]string{filepath.Dir(skillPath), copyPath})
+	if err != nil {
+		t.Fatalf("Adopt() error = %v", err)
+	}
+	if len(adopted) != 2 || adopted[1].File.Filename != "deploy.md" || adopted[1].Modified {
+		t.Errorf("Adopt() = %+v, want the command matched by content", adopted)
+	}
+
+	st, err := LoadState(InstallModeUser)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	for path, wantModified := range map[string]bool{skillPath: true, copyPath: false} {
+		inst := st.FindInstallation(path)
+		if inst == nil {
+			t.Errorf("%s not in state after adoption", path)
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inst.IsModified(content) != wantModified {
+			t.Errorf("%s modified = %v, want %v", path, !wantModified, wantModified)
+		}
+	}
+	if content, _ := os.ReadFile(skillPath); !bytes.Equal(content, edited) {
+		t.Errorf("adopted skill was rewritten: %q", content)
+	}
+
+	if _, err := Adopt([]string{skillPath}); err == nil || !strings.Contains(err.Error(), "already managed") {
+		t.Errorf("Adopt() of a managed file error = %v, want it refused", err)
+	}
+}
+
+// TestRestore verifies restoring a deleted file from the catalog or as it was installed
+func TestRestore(t *testing.T) {
+	v1 := []byte("---\nname: deploy\nversion: 1\n---\nv1\n")
+	v2 := []byte("---\nname: deploy\nversion: 2\n---\nv2\n")
+	catalog := fstest.MapFS{"categories/development/commands/deploy.md": {Data: v1}}
+	home := setupUserInstall(t, catalog)
+	if err := InstallSingleFile("development", "commands", "deploy.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+
+	claudeDir := filepath.Join(home, ".claude")
+	path := filepath.Join(claudeDir, "commands", "ccf-development-deploy.md")
+	installation := func() state.Installation {
+		st, err := LoadState(InstallModeUser)
+		if err != nil {
+			t.Fatalf("LoadState() error = %v", err)
+		}
+		inst := st.FindInstallation(path)
+		if inst == nil {
+			t.Fatalf("%s not in state", path)
+		}
+		return *inst
+	}
+	restore := func(asInstalled bool, want []byte) {
+		t.Helper()
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+		if err := Restore(path, asInstalled); err != nil {
+			t.Fatalf("Restore(%v) error = %v", asInstalled, err)
+		}
+		if content, err := os.ReadFile(path); err != nil || !bytes.Equal(content, want) {
+			t.Errorf("Restore(%v) wrote %q, %v; want %q", asInstalled, content, err, want)
+		}
+	}
+
+	if fromCatalog, asInstalled := RestoreChoices(claudeDir, installation()); !fromCatalog || asInstalled {
+		t.Errorf("RestoreChoices() = %v, %v for an unchanged catalog, want only from the catalog", fromCatalog, asInstalled)
+	}
+
+	// Once the catalog has moved on, the installed version can still be written back
+	catalog["categories/development/commands/deploy.md"] = &fstest.MapFile{Data: v2}
+	if fromCatalog, asInstalled := RestoreChoices(claudeDir, installation()); !fromCatalog || !asInstalled {
+		t.Errorf("RestoreChoices() = %v, %v for a changed catalog, want both", fromCatalog, asInstalled)
+	}
+	restore(true, v1)
+	if inst := installation(); inst.Version != "1" {
+		t.Errorf("restoring as installed recorded version %q, want 1", inst.Version)
+	}
+
+	restore(false, v2)
+	if inst := installation(); inst.Version != "2" || inst.IsModified(v2) {
+		t.Errorf("restoring from the catalog recorded %+v, want version 2", inst)
+	}
+
+	delete(catalog, "categories/development/commands/deploy.md")
+	if fromCatalog, _ := RestoreChoices(claudeDir, installation()); fromCatalog {
+		t.Errorf("RestoreChoices() offers the catalog for a file it no longer has")
+	}
+	if err := Restore(path, false); err == nil || !strings.Contains(err.Error(), "no longer in the catalog") {
+		t.Errorf("Restore() from the catalog without the file error = %v", err)
+	}
+}
+
+// TestSyncLockfile verifies that sync reinstalls locked files, removes unlocked ones, and refuses a catalog that differs from the lock
+func TestSyncLockfile(t *testing.T) {
+	deploy := []byte("---\nname: deploy\n---\nDeploy\n")
+	catalog := fstest.MapFS{
+		"categories/development/commands/deploy.md": {Data: deploy},
+		"categories/development/commands/review.md": {Data: []byte("---\nname: review\n---\nReview\n")},
+	}
+	setupUserInstall(t, catalog)
+	project := t.TempDir()
+	t.Chdir(project)
+	CurrentInstallMode = InstallModeProject
+
+	claudeDir := filepath.Join(project, ".claude")
+	deployPath := filepath.Join(claudeDir, "commands", "ccf-development-deploy.md")
+	reviewPath := filepath.Join(claudeDir, "commands", "ccf-development-review.md")
+	lockPath := filepath.Join(claudeDir, "cc-foundry.lock")
+
+	if err := InstallSingleFile("development", "commands", "deploy.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+	locked, err := os.ReadFile(lockPath)
+	if err != nil {
+		t.Fatalf("lockfile not written: %v", err)
+	}
+	if ignore, err := os.ReadFile(filepath.Join(claudeDir, ".gitignore")); err != nil || !strings.Contains(string(ignore), "/.cc-foundry.json*") {
+		t.Errorf("first project install wrote .gitignore %q, %v; want the state ignored", ignore, err)
+	}
+	if err := InstallSingleFile("development", "commands", "review.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+
+	// Only deploy is locked, and its installed copy has gone missing
+	if err := os.WriteFile(lockPath, locked, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(deployPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SyncLockfile(); err != nil {
+		t.Fatalf("SyncLockfile() error = %v", err)
+	}
+	if content, err := os.ReadFile(deployPath); err != nil || !bytes.Equal(content, deploy) {
+		t.Errorf("SyncLockfile() left deploy as %q, %v; want it reinstalled", content, err)
+	}
+	if _, err := os.Stat(reviewPath); !os.IsNotExist(err) {
+		t.Errorf("SyncLockfile() kept an installation the lockfile does not mention: %v", err)
+	}
+	st, err := LoadState(InstallModeProject)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if len(st.Installations) != 1 || st.FindInstallation(deployPath) == nil {
+		t.Errorf("state after sync = %+v, want only deploy", st.Installations)
+	}
+
+	catalog["categories/development/commands/deploy.md"] = &fstest.MapFile{Data: []byte("---\nname: deploy\n---\nChanged\n")}
+	if err := SyncLockfile(); err == nil || !strings.Contains(err.Error(), "does not match locked sha256") {
+		t.Errorf("SyncLockfile() with a changed catalog error = %v, want it refused", err)
+	}
+	if content, _ := os.ReadFile(deployPath); !bytes.Equal(content, deploy) {
+		t.Errorf("refused sync rewrote deploy: %q", content)
+	}
+}
+
+// TestSeparateSourcesInstall verifies that two sources shipping the same file install, lock, sync,
+// and restore side by side under qualify_source
+func TestSeparateSourcesInstall(t *testing.T) {
+	setupUserInstall(t, nil)
+	project := t.TempDir()
+	t.Chdir(project)
+	CurrentInstallMode = InstallModeProject
+	Naming = embedpkg.NamingConfig{QualifySource: true}
+	embedpkg.SeparateSources = true
+
+	content := map[string][]byte{
+		"team": []byte("---\nname: x\n---\nteam\n"),
+		"acme": []byte("---\nname: x\n---\nacme\n"),
+	}
+	builtin := fstest.MapFS{"development/commands/deploy.md": {Data: []byte("---\nname: deploy\n---\n")}}
+	var sources []embedpkg.Source
+	for _, name := range []string{"team", "acme"} {
+		root := fstest.MapFS{"development/skills/x.md": {Data: content[name]}}
+		sources = append(sources, embedpkg.Source{Config: embedpkg.SourceConfig{Name: name}, Root: root})
+	}
+	if err := embedpkg.Use(builtin, sources); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+
+	claudeDir := filepath.Join(project, ".claude")
+	paths := map[string]string{
+		"team": filepath.Join(claudeDir, "skills", "ccf-team-development-x", "SKILL.md"),
+		"acme": filepath.Join(claudeDir, "skills", "ccf-acme-development-x", "SKILL.md"),
+	}
+	checkInstalled := func(when string) {
+		t.Helper()
+		for source, path := range paths {
+			if got, err := os.ReadFile(path); err != nil || !bytes.Equal(got, content[source]) {
+				t.Errorf("%s: %s copy is %q, %v; want %q", when, source, got, err, content[source])
+			}
+		}
+	}
+
+	if err := InstallType("development", "skills"); err != nil {
+		t.Fatalf("InstallType() error = %v", err)
+	}
+	checkInstalled("after install")
+
+	st, err := LoadState(InstallModeProject)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	lock, err := lockfile.Load(claudeDir)
+	if err != nil {
+		t.Fatalf("lockfile.Load() error = %v", err)
+	}
+	for source, path := range paths {
+		if inst := st.FindInstallation(path); inst == nil || inst.Source != source {
+			t.Errorf("state records %s as %+v, want source %s", path, inst, source)
+		}
+		if entry := lock.Find(source, "development", "skills", "x.md"); entry == nil || entry.SHA256 != lockfile.Hash(content[source]) {
+			t.Errorf("lockfile entry for %s = %+v, want its own hash", source, entry)
+		}
+	}
+
+	// Each copy is synced and restored from its own source
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SyncLockfile(); err != nil {
+		t.Fatalf("SyncLockfile() error = %v", err)
+	}
+	checkInstalled("after sync")
+
+	if err := os.Remove(paths["acme"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore(paths["acme"], false); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	checkInstalled("after restore")
+}
+
+// TestSourceNameCollision verifies that without qualify_source a file from a second source does not
+// take over the installation of the same file from the first one
+func TestSourceNameCollision(t *testing.T) {
+	home := setupUserInstall(t, nil)
+
+	useSources := func(names ...string) {
+		t.Helper()
+		var sources []embedpkg.Source
+		for _, name := range names {
+			root := fstest.MapFS{"development/skills/x.md": {Data: []byte("---\nname: x\n---\n" + name + "\n")}}
+			sources = append(sources, embedpkg.Source{Config: embedpkg.SourceConfig{Name: name}, Root: root})
+		}
+		if err := embedpkg.Use(fstest.MapFS{}, sources); err != nil {
+			t.Fatalf("Use() error = %v", err)
+		}
+	}
+
+	useSources("team", "acme")
+	if err := InstallSingleFile("development", "skills", "x.md"); err != nil {
+		t.Fatalf("InstallSingleFile() error = %v", err)
+	}
+
+	// Listing acme first makes its copy the catalog's, under the name team's copy has
+	useSources("acme", "team")
+	err := InstallSingleFile("development", "skills", "x.md")
+	if err == nil || !strings.Contains(err.Error(), "already used by development/skills/x from team") {
+		t.Errorf("InstallSingleFile() error = %v, want the name collision with team's copy", err)
+	}
+
+	path := filepath.Join(home, ".claude", "skills", "ccf-development-x", "SKILL.md")
+	if content, err := os.ReadFile(path); err != nil || string(content) != "---\nname: x\n---\nteam\n" {
+		t.Errorf("installed content = %q, %v; want team's copy", content, err)
+	}
+	st, err := LoadState(InstallModeUser)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if inst := st.FindInstallation(path); inst == nil || inst.Source != "team" {
+		t.Errorf("installation = %+v, want team's copy", inst)
+	}
+	if inst := findInstalled(st, "acme", "development", "skills", "x.md"); inst != nil {
+		t.Errorf("findInstalled(acme) = %+v, want nil", inst)
+	}
+}
+
+// TestDiffViewModel verifies that scrolling the diff viewer stays within the diff
+func TestDiffViewModel(t *testing.T) {
+	m := diffViewModel{lines: make([]string, 10), height: 4}
+
+	press := func(m diffViewModel, key tea.KeyMsg) diffViewModel {
+		updated, _ := m.Update(key)
+		return updated.(diffViewModel)
+	}
+
+	up := tea.KeyMsg{Type: tea.KeyUp}
+	pgDown := tea.KeyMsg{Type: tea.KeyPgDown}
+	end := tea.KeyMsg{Type: tea.KeyEnd}
+
+	if m = press(m, up); m.offset != 0 {
+		t.Errorf("offset after up at top = %d, want 0", m.offset)
+	}
+	if m = press(m, pgDown); m.offset != 4 {
+		t.Errorf("offset after page down = %d, want 4", m.offset)
+	}
+	if m = press(m, end); m.offset != 6 {
+		t.Errorf("offset after end = %d, want 6 (last page)", m.offset)
+	}
+	if m = press(m, pgDown); m.offset != 6 {
+		t.Errorf("offset after page down at bottom = %d, want 6", m.offset)
+	}
+}
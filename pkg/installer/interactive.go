@@ -18,7 +18,8 @@ type menuModel struct {
 	disabled   []bool // whether each option is disabled
 	selected   int
 	canceled   bool
-	showBanner bool // whether to show the banner at the top
+	showBanner bool   // whether to show the banner at the top
+	help       string // help text override (default: navigate/select/back)
 }
 
 // Init implements tea.Model
@@ -100,7 +101,11 @@ func (m menuModel) View() string {
 	}
 
 	// Help text at bottom
-	helpText := helpStyle.Render("Navigate: ↑/↓  Select: Enter (↵)  Back: Esc")
+	help := m.help
+	if help == "" {
+		help = "Navigate: ↑/↓  Select: Enter (↵)  Back: Esc"
+	}
+	helpText := helpStyle.Render(help)
 
 	// Combine all elements
 	content += "\n\n" + menuItems + "\n" + helpText
@@ -279,6 +284,28 @@ func PromptForLocationForRemoval(category, fileType string) bool {
 		return false
 	}
 
+	return promptForRemovalLocation(avail)
+}
+
+// PromptForLocationForProfileRemoval prompts for the location to remove a profile from
+// Returns true to proceed, false to cancel or nothing to remove
+func PromptForLocationForProfileRemoval(name string) bool {
+	avail, err := CheckProfileLocationAvailability(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking locations: %v\n", err)
+		return false
+	}
+
+	if !avail.HasUserLevel && !avail.HasProjectLevel {
+		fmt.Printf("\nNo files installed from profile '%s'\n", name)
+		return false
+	}
+
+	return promptForRemovalLocation(avail)
+}
+
+// promptForRemovalLocation asks which location to remove from, offering only locations with files
+func promptForRemovalLocation(avail LocationAvailability) bool {
 	// Always show both locations, disable the ones with 0 files
 	fmt.Println()
 	cwd, _ := os.Getwd()
@@ -323,69 +350,110 @@ func PromptForLocationForRemoval(category, fileType string) bool {
 
 // PreviewChange represents a single file change to preview
 type PreviewChange struct {
-	Action      string // "install", "update", "skip"
+	Action      string // "install", "update", "downgrade", "skip"
 	Type        string // "command", "agent", "skill"
 	Name        string // Display name
 	Path        string // Installation path
 	IsUnchanged bool   // True if file content hasn't changed
+	IsModified  bool   // True if the installed copy has local edits
+	Version     string // Catalog version label, e.g. "v4" (empty if unversioned)
+	Transition  string // Version change of an update, e.g. "v3 → v4" (empty if unknown)
+	Assets      int    // Number of supporting files in a skill package
+	RequiredBy  string // Selected file that pulls this dependency in (empty if selected directly)
+	Description string // Short catalog description
 }
 
 // PreviewInstall shows what will be installed and asks for confirmation
 func PreviewInstall(category string, fileType string) (bool, error) {
-	var files []embedpkg.CategoryFile
-	var err error
+	sel := Selector{Category: category, Type: fileType}
 
-	if category == "" {
-		files, err = embedpkg.ListAllFiles()
-		if err != nil {
-			return false, fmt.Errorf("failed to list files: %w", err)
+	files, err := ListSelectedFiles(sel)
+	if err != nil {
+		return false, err
+	}
+
+	return confirmInstallPreview(sel.String(), files)
+}
+
+// PreviewInstallFiles shows what installing the given files will change and asks for confirmation
+func PreviewInstallFiles(files []embedpkg.CategoryFile) (bool, error) {
+	return confirmInstallPreview(fmt.Sprintf("%d selected files", len(files)), files)
+}
+
+// confirmInstallPreview prints the install preview and asks for confirmation
+// When files would be updated, the changes can be viewed before deciding
+func confirmInstallPreview(title string, files []embedpkg.CategoryFile) (bool, error) {
+	for {
+		if err := printInstallPreview(title, files); err != nil {
+			return false, err
 		}
-	} else if fileType != "" {
-		files, err = embedpkg.ListTypeFiles(category, fileType)
+
+		diffs, err := catalogUpdateDiffs(files)
 		if err != nil {
-			return false, fmt.Errorf("failed to list files: %w", err)
+			return false, err
 		}
-	} else {
-		files, err = embedpkg.ListCategoryFiles(category)
-		if err != nil {
-			return false, fmt.Errorf("failed to list files: %w", err)
+		if diffs == "" {
+			return confirmInline("Proceed with installation?", "Yes, proceed")
 		}
-	}
 
-	if len(files) == 0 {
-		if category == "" {
-			return false, fmt.Errorf("no installable files found")
+		options := []string{
+			"Yes, proceed",
+			"View changes",
+			"No, cancel",
 		}
-		if fileType != "" {
-			return false, fmt.Errorf("no %s found in category '%s'", fileType, category)
+
+		selected, err := SelectOptionInline("Proceed with installation?", options)
+		if err != nil {
+			return false, err
 		}
-		return false, fmt.Errorf("no files found in category '%s'", category)
+
+		switch selected {
+		case 0:
+			return true, nil
+		case 1:
+			if err := ShowDiff(fmt.Sprintf("Changes: %s [%s]", title, GetInstallModeDescription()), diffs); err != nil {
+				return false, err
+			}
+		default:
+			return false, nil
+		}
+	}
+}
+
+// PrintInstallPreview prints what installing a selection would change without prompting
+func PrintInstallPreview(sel Selector) error {
+	files, err := ListSelectedFiles(sel)
+	if err != nil {
+		return err
 	}
 
+	return printInstallPreview(sel.String(), files)
+}
+
+// printInstallPreview prints the install preview for a list of catalog files
+func printInstallPreview(title string, files []embedpkg.CategoryFile) error {
 	// Load state to check for existing installations
-	st, err := state.Load()
+	st, err := LoadState(CurrentInstallMode)
+	if err != nil {
+		return err
+	}
+
+	baseDir, err := GetClaudeCodeDir()
+	if err != nil {
+		return err
+	}
+
+	// Selected files come after the dependencies they pull in
+	plan, err := PlanInstall(st, files)
 	if err != nil {
-		return false, fmt.Errorf("failed to load state: %w", err)
+		return err
 	}
 
 	// Build preview
 	var changes []PreviewChange
-	for _, file := range files {
-		var installPath string
-		var displayName string
-
-		if file.Type == "skills" {
-			skillName := GenerateInstalledFilename(file.Category, file.Filename)
-			skillName = strings.TrimSuffix(skillName, ".md")
-			typeDir, _ := GetTypeDir(file.Type)
-			installPath = fmt.Sprintf("%s/%s/SKILL.md", typeDir, skillName)
-			displayName = fmt.Sprintf("%s/SKILL.md", skillName)
-		} else {
-			fileName := GenerateInstalledFilename(file.Category, file.Filename)
-			typeDir, _ := GetTypeDir(file.Type)
-			installPath = fmt.Sprintf("%s/%s", typeDir, fileName)
-			displayName = fileName
-		}
+	for _, file := range plan.Files {
+		installPath := installedPathOf(st, file)
+		displayName, _ := filepath.Rel(filepath.Join(baseDir, file.Type), installPath)
 
 		// Replace home with ~ for display
 		displayPath := installPath
@@ -394,13 +462,21 @@ func PreviewInstall(category string, fileType string) (bool, error) {
 		}
 
 		// Check if already installed
-		existing := st.FindInstallation(installPath)
+		existing := findInstalled(st, file.Source, file.Category, file.Type, file.Filename)
 		action := "install"
 		isUnchanged := false
+		isModified := false
+		transition := ""
 
 		if existing != nil {
-			if existing.HasContentChanged(file.Content) {
+			if packageChanged(existing, file) {
+				installed := installedVersion(existing, baseDir)
 				action = "update"
+				if isDowngrade(installed, file) {
+					action = "downgrade"
+				}
+				isModified = hasLocalChanges(existing)
+				transition = versionTransition(installed, file.Metadata.Version)
 			} else {
 				action = "skip"
 				isUnchanged = true
@@ -414,78 +490,154 @@ func PreviewInstall(category string, fileType string) (bool, error) {
 			Name:        displayName,
 			Path:        displayPath,
 			IsUnchanged: isUnchanged,
+			IsModified:  isModified,
+			Version:     file.Metadata.VersionLabel(),
+			Transition:  transition,
+			Assets:      len(file.Assets),
+			RequiredBy:  plan.RequiredBy[embedpkg.Reference(file.Category, file.Type, file.Filename)],
+			Description: file.Metadata.ShortDescription(72),
 		})
 	}
 
 	// Clear screen and display banner and preview
-	fmt.Print("\033[H\033[2J") // Clear screen
-	fmt.Println(bannerStyle.Render(banner))
-	if category == "" {
-		fmt.Printf("Preview: all categories [%s]\n", GetInstallModeDescription())
-	} else {
-		fmt.Printf("Preview: %s [%s]\n", category, GetInstallModeDescription())
-	}
+	ShowBanner()
+	fmt.Printf("Preview: %s [%s]\n", title, GetInstallModeDescription())
 	fmt.Println()
 
 	installCount := 0
 	updateCount := 0
 	skipCount := 0
+	refusedCount := 0
 
 	for _, change := range changes {
+		name := change.Name
+		switch {
+		case change.Transition != "":
+			name += " (" + change.Transition + ")"
+		case change.Version != "":
+			name += " (" + change.Version + ")"
+		}
+		if change.Assets > 0 {
+			name += fmt.Sprintf(" +%d files", change.Assets)
+		}
+
 		switch change.Action {
 		case "install":
-			fmt.Printf("  + %s: %s → %s\n", change.Type, change.Name, change.Path)
+			if change.RequiredBy != "" {
+				fmt.Printf("  + %s: %s → %s (required by %s)\n", change.Type, name, change.Path, change.RequiredBy)
+			} else {
+				fmt.Printf("  + %s: %s → %s\n", change.Type, name, change.Path)
+			}
 			installCount++
 		case "update":
-			fmt.Printf("  ↻ %s: %s → %s (will update)\n", change.Type, change.Name, change.Path)
+			if change.IsModified {
+				fmt.Printf("  ↻ %s: %s → %s (will update, has local changes)\n", change.Type, name, change.Path)
+			} else {
+				fmt.Printf("  ↻ %s: %s → %s (will update)\n", change.Type, name, change.Path)
+			}
 			updateCount++
+		case "downgrade":
+			if AllowDowngrade {
+				fmt.Printf("  ↻ %s: %s → %s (will downgrade)\n", change.Type, name, change.Path)
+				updateCount++
+			} else {
+				fmt.Printf("  ⚠ %s: %s → %s (downgrade refused, use --force)\n", change.Type, name, change.Path)
+				refusedCount++
+			}
 		case "skip":
-			fmt.Printf("  · %s: %s → %s (unchanged)\n", change.Type, change.Name, change.Path)
+			fmt.Printf("  · %s: %s → %s (unchanged)\n", change.Type, name, change.Path)
 			skipCount++
 		}
-	}
 
-	fmt.Println()
-	fmt.Printf("Summary: %d to install, %d to update, %d unchanged\n", installCount, updateCount, skipCount)
-	fmt.Println()
+		if change.Description != "" && change.Action != "skip" {
+			fmt.Printf("      %s\n", change.Description)
+		}
+	}
 
-	// Ask for confirmation (use inline menu to preserve preview above)
-	options := []string{
-		"Yes, proceed",
-		"No, cancel",
+	if len(plan.Recommended) > 0 {
+		fmt.Println()
+		fmt.Println("  Recommended (not selected):")
+		for _, ref := range plan.Recommended {
+			fmt.Printf("      %s\n", ref)
+		}
 	}
 
-	selected, err := SelectOptionInline("Proceed with installation?", options)
-	if err != nil {
-		return false, err
+	fmt.Println()
+	fmt.Printf("Summary: %d to install, %d to update, %d unchanged", installCount, updateCount, skipCount)
+	if refusedCount > 0 {
+		fmt.Printf(", %d downgrades refused", refusedCount)
 	}
+	fmt.Println()
+	fmt.Println()
 
-	return selected == 0, nil
+	return nil
 }
 
 // PreviewRemove shows what will be removed and asks for confirmation
 func PreviewRemove(category string, fileType string) (bool, error) {
-	st, err := state.Load()
+	sel := Selector{Category: category, Type: fileType}
+
+	installations, err := ListSelectedInstallations(sel)
 	if err != nil {
-		return false, fmt.Errorf("failed to load state: %w", err)
+		return false, err
 	}
-
-	installations := ListInstallationsForCurrentMode(st, category, fileType)
 	if len(installations) == 0 {
 		// No files to remove - skip preview and return true to continue
 		return true, nil
 	}
 
-	// Clear screen and display banner and preview
-	fmt.Print("\033[H\033[2J") // Clear screen
-	fmt.Println(bannerStyle.Render(banner))
-	if fileType != "" {
-		fmt.Printf("Preview: Remove %s from %s [%s]\n", fileType, category, GetInstallModeDescription())
-	} else if category == "" {
-		fmt.Printf("Preview: Remove all categories [%s]\n", GetInstallModeDescription())
+	if err := PrintRemovePreview(sel); err != nil {
+		return false, err
+	}
+
+	return confirmInline("Proceed with removal?", "Yes, remove")
+}
+
+// PreviewRemoveInstallations shows which installed files will be removed and asks for confirmation
+func PreviewRemoveInstallations(installations []state.Installation) (bool, error) {
+	printRemovePreview(fmt.Sprintf("Remove %d selected files", len(installations)), installations, nil, "")
+
+	return confirmInline("Proceed with removal?", "Yes, remove")
+}
+
+// PrintRemovePreview prints what removing a selection would delete without prompting
+func PrintRemovePreview(sel Selector) error {
+	if sel.Profile != "" {
+		st, err := LoadState(CurrentInstallMode)
+		if err != nil {
+			return err
+		}
+		removing, kept := profileInstallations(st, sel.Profile)
+		printRemovePreview(fmt.Sprintf("Remove %s", sel), removing, kept, sel.Profile)
+		return nil
+	}
+
+	installations, err := ListSelectedInstallations(sel)
+	if err != nil {
+		return err
+	}
+
+	var title string
+	if sel.File != "" {
+		title = fmt.Sprintf("Remove %s", sel)
+	} else if sel.Type != "" {
+		title = fmt.Sprintf("Remove %s from %s", sel.Type, sel.Category)
+	} else if sel.IsAll() {
+		title = "Remove all categories"
 	} else {
-		fmt.Printf("Preview: Remove category %s [%s]\n", category, GetInstallModeDescription())
+		title = fmt.Sprintf("Remove category %s", sel.Category)
 	}
+
+	printRemovePreview(title, installations, nil, "")
+	return nil
+}
+
+// printRemovePreview prints the removal preview for a list of installations
+// kept lists files removing profile leaves in place because another profile brought them in too
+func printRemovePreview(title string, installations, kept []state.Installation, profile string) {
+	// Clear screen and display banner and preview
+	ShowBanner()
+	fmt.Printf("Preview: %s [%s]\n", title, GetInstallModeDescription())
 	fmt.Println()
 
 	for _, inst := range installations {
@@ -496,18 +648,27 @@ func PreviewRemove(category string, fileType string) (bool, error) {
 		typeLabel := strings.TrimSuffix(inst.Type, "s")
 		fmt.Printf("  - %s: %s\n", typeLabel, displayPath)
 	}
+	for _, inst := range kept {
+		fmt.Printf("  = %s: %s (kept, %s)\n", strings.TrimSuffix(inst.Type, "s"), shortenHome(inst.InstalledPath), otherProfiles(inst, profile))
+	}
+
+	if st, err := LoadState(CurrentInstallMode); err == nil {
+		warnBrokenDependents(st, installations)
+	}
 
 	fmt.Println()
 	fmt.Printf("Summary: %d files will be removed\n", len(installations))
 	fmt.Println()
+}
 
-	// Ask for confirmation (use inline menu to preserve preview above)
+// confirmInline asks a yes/no question with an inline menu to preserve the preview above
+func confirmInline(prompt, yesLabel string) (bool, error) {
 	options := []string{
-		"Yes, remove",
+		yesLabel,
 		"No, cancel",
 	}
 
-	selected, err := SelectOptionInline("Proceed with removal?", options)
+	selected, err := SelectOptionInline(prompt, options)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,201 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/lockfile"
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+// commitChanges commits a transaction, saving the state and lockfile as its final step
+// Both files are restored together with the installed files if anything fails
+func commitChanges(tx *Transaction, installed []embedpkg.CategoryFile, removed []state.Installation) error {
+	st := tx.State()
+	tx.Guard(state.GetStateFilePath(st.Dir()))
+	if CurrentInstallMode == InstallModeProject {
+		tx.Guard(lockfile.GetLockFilePath(st.Dir()))
+	}
+
+	if err := tx.Commit(func() error {
+		return saveChanges(st, installed, removed)
+	}); err != nil {
+		return err
+	}
+
+	// Best effort: a leftover original only costs disk space
+	st.PruneOriginals()
+	return nil
+}
+
+// saveChanges saves the state and, in project mode, records the change in .claude/cc-foundry.lock
+func saveChanges(st *state.State, installed []embedpkg.CategoryFile, removed []state.Installation) error {
+	if err := st.Save(); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	if CurrentInstallMode != InstallModeProject {
+		return nil
+	}
+
+	claudeDir, err := GetClaudeCodeDir()
+	if err != nil {
+		return err
+	}
+
+	lock, err := lockfile.Load(claudeDir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range installed {
+		// A refused downgrade leaves the installed version in place, and the lock keeps pinning it
+		inst := findInstalled(st, file.Source, file.Category, file.Type, file.Filename)
+		if inst != nil && packageChanged(inst, file) {
+			continue
+		}
+		lock.Set(file.Source, file.Category, file.Type, file.Filename, file.Content, file.Assets)
+	}
+	for _, inst := range removed {
+		lock.Remove(inst.Source, inst.Category, inst.Type, inst.File)
+	}
+
+	return lock.Save(claudeDir)
+}
+
+// SyncLockfile installs exactly the files pinned in the project lockfile
+// Files whose catalog content no longer matches the pinned hash are refused,
+// and project installations that are not in the lockfile are removed
+func SyncLockfile() error {
+	CurrentInstallMode = InstallModeProject
+
+	claudeDir, err := GetClaudeCodeDir()
+	if err != nil {
+		return err
+	}
+
+	if !lockfile.Exists(claudeDir) {
+		return fmt.Errorf("no lockfile found at %s", lockfile.GetLockFilePath(claudeDir))
+	}
+
+	lock, err := lockfile.Load(claudeDir)
+	if err != nil {
+		return err
+	}
+
+	// Resolve every entry before touching the disk
+	var files []embedpkg.CategoryFile
+	var problems []string
+	for _, entry := range lock.Entries {
+		ref := fmt.Sprintf("%s/%s/%s", entry.Category, entry.Type, entry.File)
+		if entry.Source != "" {
+			ref += " from " + entry.Source
+		}
+		file, err := embedpkg.GetSourceFile(entry.Source, entry.Category, entry.Type, entry.File)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: not in catalog", ref))
+			continue
+		}
+		if !entry.Matches(file.Content, file.Assets) {
+			problems = append(problems, fmt.Sprintf("%s: catalog content does not match locked sha256", ref))
+			continue
+		}
+		files = append(files, *file)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("lockfile cannot be satisfied by this catalog:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	// The lockfile is authoritative, so pinned versions are installed even when older
+	previousAllowDowngrade := AllowDowngrade
+	AllowDowngrade = true
+	defer func() { AllowDowngrade = previousAllowDowngrade }()
+
+	st, unlock, err := lockState(CurrentInstallMode)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ShowBanner()
+	fmt.Printf("Syncing %d files from %s [%s]\n", len(files), lockfile.LockFile, GetInstallModeDescription())
+
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+
+	for _, file := range files {
+		// Force a rewrite when the file on disk is missing or differs from the lock
+		inst := findInstalled(st, file.Source, file.Category, file.Type, file.Filename)
+		if inst != nil && installedFilesMatch(inst, file) {
+			inst = nil
+		}
+		if inst != nil {
+			st.RemoveInstallation(inst.InstalledPath)
+		}
+
+		if err := installFileAs(file, tx, inst); err != nil {
+			return err
+		}
+		if inst != nil {
+			for _, profile := range inst.Profiles {
+				st.AddProfile(inst.InstalledPath, profile)
+			}
+		}
+	}
+
+	// Remove project installations the lockfile does not mention
+	var removed []state.Installation
+	for _, inst := range st.ListInstallations("", "") {
+		if lock.Find(inst.Source, inst.Category, inst.Type, inst.File) != nil {
+			continue
+		}
+		RemoveInstallation(inst, tx)
+		removed = append(removed, inst)
+	}
+
+	tx.Guard(state.GetStateFilePath(claudeDir))
+	if err := tx.Commit(func() error {
+		if err := st.Save(); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	st.PruneOriginals()
+
+	fmt.Printf("\n✓ Synced %d files, removed %d not in lockfile [%s]\n", len(files), len(removed), GetInstallModeDescription())
+	return nil
+}
+
+// installedFilesMatch reports whether a catalog file and its supporting files are installed with exactly their content
+// A template matches when the installation was rendered from the same content and is unchanged on disk
+func installedFilesMatch(inst *state.Installation, file embedpkg.CategoryFile) bool {
+	installedPath := inst.InstalledPath
+	hash := lockfile.Hash(file.Content)
+	if inst.Hash == hash {
+		hash = inst.InstalledHash()
+	}
+	if !fileMatchesHash(installedPath, hash) {
+		return false
+	}
+	for name, content := range file.Assets {
+		if !fileMatchesHash(filepath.Join(filepath.Dir(installedPath), filepath.FromSlash(name)), lockfile.Hash(content)) {
+			return false
+		}
+	}
+	return true
+}
+
+// fileMatchesHash reports whether a file exists and its content has the given SHA-256 hash
+func fileMatchesHash(path, hash string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return lockfile.Hash(content) == hash
+}
@@ -2,8 +2,10 @@ package installer
 
 import (
 	"fmt"
+	"strings"
 
 	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/state"
 )
 
 // MainMenuOption represents a main menu choice
@@ -67,8 +69,9 @@ func ShowMainMenu(lastSelected int) (MainMenuOption, int, error) {
 	}
 }
 
-// ShowCategoryMenu displays available categories and returns the selected category
+// ShowCategoryMenu displays available categories and profiles and returns the selected one
 // action parameter is used for display purposes ("list", "install", "remove")
+// A profile is returned as profile:<name>; for install/remove it may also return "all" or "files" (pick individual files)
 func ShowCategoryMenu(action string) (string, error) {
 	categories, err := embedpkg.ListCategories()
 	if err != nil {
@@ -103,9 +106,16 @@ func ShowCategoryMenu(action string) (string, error) {
 		options = append(options, fmt.Sprintf("%s (%s)", category, countStr))
 	}
 
-	// Add "All categories" option at the beginning for install/remove
+	// Profiles follow the categories; the config file has already been reported if it is broken
+	profiles, _ := embedpkg.ListProfiles()
+	for _, profile := range profiles {
+		options = append(options, fmt.Sprintf("profile: %s (%d files)", profile.Name, len(profile.Files)))
+	}
+
+	// Add "All categories" at the beginning and "Choose individual files" at the end for install/remove
 	if action == "install" || action == "remove" {
 		options = append([]string{"All categories"}, options...)
+		options = append(options, "Choose individual files...")
 	}
 
 	prompt := fmt.Sprintf("Select category to %s", action)
@@ -117,9 +127,14 @@ func ShowCategoryMenu(action string) (string, error) {
 		return "", err
 	}
 
-	// Handle "All categories" selection
-	if (action == "install" || action == "remove") && selected == 0 {
-		return "all", nil
+	// Handle "All categories" and "Choose individual files" selections
+	if action == "install" || action == "remove" {
+		if selected == 0 {
+			return "all", nil
+		}
+		if selected == len(options)-1 {
+			return "files", nil
+		}
 	}
 
 	// Adjust index if "All categories" was added
@@ -128,6 +143,9 @@ func ShowCategoryMenu(action string) (string, error) {
 		categoryIndex = selected - 1
 	}
 
+	if profileIndex := categoryIndex - len(categories); profileIndex >= 0 && profileIndex < len(profiles) {
+		return ProfileSelectorPrefix + profiles[profileIndex].Name, nil
+	}
 	if categoryIndex < 0 || categoryIndex >= len(categories) {
 		return "", fmt.Errorf("invalid category selection")
 	}
@@ -135,6 +153,83 @@ func ShowCategoryMenu(action string) (string, error) {
 	return categories[categoryIndex], nil
 }
 
+// SelectCatalogFiles shows a checklist of every catalog file and returns the checked ones
+// Returns nil without error if the user cancels or checks nothing
+func SelectCatalogFiles() ([]embedpkg.CategoryFile, error) {
+	files, err := embedpkg.ListAllFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no installable files found")
+	}
+
+	var options []string
+	for _, file := range files {
+		option := formatFileOption(file.Category, file.Type, file.Filename)
+		if embedpkg.SeparateSources && file.Source != "" {
+			option += " (" + file.Source + ")"
+		}
+		if summary := file.Summary(48); summary != "" {
+			option += "  " + summary
+		}
+		options = append(options, option)
+	}
+
+	indices, err := SelectMultiple("Select files to install", options, false)
+	if err != nil {
+		if err.Error() == "cancelled by user" {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var selected []embedpkg.CategoryFile
+	for _, i := range indices {
+		selected = append(selected, files[i])
+	}
+	return selected, nil
+}
+
+// SelectInstalledFiles shows a checklist of files installed in the current mode and returns the checked ones
+// Returns nil without error if the user cancels or checks nothing
+func SelectInstalledFiles() ([]state.Installation, error) {
+	st, err := LoadState(CurrentInstallMode)
+	if err != nil {
+		return nil, err
+	}
+
+	installations := st.ListInstallations("", "")
+	if len(installations) == 0 {
+		return nil, nil
+	}
+
+	var options []string
+	for _, inst := range installations {
+		options = append(options, formatFileOption(inst.Category, inst.Type, inst.File))
+	}
+
+	indices, err := SelectMultiple("Select files to remove", options, false)
+	if err != nil {
+		if err.Error() == "cancelled by user" {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var selected []state.Installation
+	for _, i := range indices {
+		selected = append(selected, installations[i])
+	}
+	return selected, nil
+}
+
+// formatFileOption formats a catalog file as a picker option, e.g. "development › skill › project-layout-go"
+func formatFileOption(category, fileType, filename string) string {
+	return fmt.Sprintf("%s › %s › %s", category, strings.TrimSuffix(fileType, "s"), strings.TrimSuffix(filename, ".md"))
+}
+
 // ShowTypeMenu displays file types (commands, agents, skills) and returns the selected type
 func ShowTypeMenu() (string, error) {
 	options := []string{
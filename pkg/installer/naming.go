@@ -0,0 +1,106 @@
+package installer
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+// Naming is the naming policy of new installs; main sets it from the config file
+// Installed files keep the name they were installed under, so changing it only affects new installs
+var Naming embedpkg.NamingConfig
+
+// namedPath returns where a naming policy installs a catalog file under a .claude directory
+func namedPath(policy embedpkg.NamingConfig, baseDir, source, category, fileType, filename string) string {
+	name := policy.Name(source, category, filename)
+	if fileType == "skills" {
+		return filepath.Join(baseDir, fileType, name, embedpkg.SkillFile)
+	}
+	return filepath.Join(baseDir, fileType, name+".md")
+}
+
+// NamePrefixes returns the name prefixes of foundry files in a state's directory: the naming
+// policy's and the ones its installations were installed under
+func NamePrefixes(st *state.State) []string {
+	prefixes := []string{Naming.NamePrefix()}
+	for _, inst := range st.Installations {
+		if prefix := NamePrefixOf(inst); !slices.Contains(prefixes, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// NamePrefixOf returns the name prefix an installation was installed under
+func NamePrefixOf(inst state.Installation) string {
+	if inst.NamePrefix == "" {
+		return embedpkg.NamingConfig{}.NamePrefix() // installed before names were recorded
+	}
+	return inst.NamePrefix
+}
+
+// findInstalled returns the installation of a catalog file from a source
+// It is found by what was installed rather than by name, falling back to the name the policy
+// gives it for installs made before sources were recorded; a copy from another source is not it
+func findInstalled(st *state.State, source, category, fileType, filename string) *state.Installation {
+	if inst := st.FindFile(source, category, fileType, filename); inst != nil {
+		return inst
+	}
+	inst := st.FindInstallation(InstalledPath(st.Dir(), source, category, fileType, filename))
+	if inst == nil || inst.Source != "" || inst.Category != category || inst.Type != fileType || inst.File != filename {
+		return nil
+	}
+	return inst
+}
+
+// installedPathOf returns where a catalog file is installed, or where it will be when it is not
+func installedPathOf(st *state.State, file embedpkg.CategoryFile) string {
+	if inst := findInstalled(st, file.Source, file.Category, file.Type, file.Filename); inst != nil {
+		return inst.InstalledPath
+	}
+	return InstalledPath(st.Dir(), file.Source, file.Category, file.Type, file.Filename)
+}
+
+// FileInstalledPath returns where a catalog file named by source, category, type, and filename is
+// installed in a state, or where the naming policy would install it
+func FileInstalledPath(st *state.State, source, category, fileType, filename string) string {
+	if inst := findInstalled(st, source, category, fileType, filename); inst != nil {
+		return inst.InstalledPath
+	}
+	return InstalledPath(st.Dir(), source, category, fileType, filename)
+}
+
+// findSelected returns the installation of the file a selector names
+func findSelected(st *state.State, sel Selector) *state.Installation {
+	return findInstalled(st, catalogSource(sel.Category, sel.Type, sel.File), sel.Category, sel.Type, sel.File)
+}
+
+// catalogSource returns the source the catalog provides a file from; files not in the catalog have none
+func catalogSource(category, fileType, filename string) string {
+	if file, err := embedpkg.GetFile(category, fileType, filename); err == nil {
+		return file.Source
+	}
+	return ""
+}
+
+// checkNameCollision refuses to install a catalog file under a name another installed file already has
+func checkNameCollision(st *state.State, file embedpkg.CategoryFile, installedPath string) error {
+	owner := st.FindInstallation(installedPath)
+	if owner == nil || (owner.Source == file.Source && owner.Category == file.Category && owner.Type == file.Type && owner.File == file.Filename) {
+		return nil
+	}
+	return fmt.Errorf("cannot install %s as %s: the name is already used by %s (set another naming.separator or naming.qualify_source in the config file)",
+		sourceReference(file.Source, file.Category, file.Type, file.Filename), shortenHome(installedPath), sourceReference(owner.Source, owner.Category, owner.Type, owner.File))
+}
+
+// sourceReference names a catalog file by its reference and, for configured sources, its source
+func sourceReference(source, category, fileType, filename string) string {
+	ref := embedpkg.Reference(category, fileType, filename)
+	if source != "" {
+		ref += " from " + source
+	}
+	return ref
+}
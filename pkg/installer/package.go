@@ -0,0 +1,99 @@
+package installer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+// packageChanged reports whether a catalog file differs from an installation, including its supporting files
+func packageChanged(inst *state.Installation, file embedpkg.CategoryFile) bool {
+	return inst.HasContentChanged(file.Content) || inst.HasAssetsChanged(file.Assets)
+}
+
+// stageAssets stages the supporting files of a skill package next to its installed SKILL.md
+// Supporting files the previous version installed but the package no longer has are removed.
+// Files edited since they were installed are kept unless the update strategy takes the catalog version;
+// the number kept is returned
+func stageAssets(existing *state.Installation, installedPath string, file embedpkg.CategoryFile, tx *Transaction) (int, error) {
+	dir := filepath.Dir(installedPath)
+	keepEdits := CurrentUpdateStrategy != UpdateTakeCatalog
+	kept := 0
+
+	names := make([]string, 0, len(file.Assets))
+	for name := range file.Assets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		current, err := os.ReadFile(target)
+		if err == nil && bytes.Equal(current, file.Assets[name]) {
+			continue
+		}
+		if err == nil && existing != nil && keepEdits && hasLocalAssetChanges(existing, name, current) {
+			kept++
+			continue
+		}
+		if err := tx.WriteMode(target, file.Assets[name], assetMode(file.Assets[name])); err != nil {
+			return kept, err
+		}
+	}
+
+	if existing == nil {
+		return kept, nil
+	}
+	for name := range existing.Assets {
+		if _, ok := file.Assets[name]; ok {
+			continue
+		}
+		current, err := os.ReadFile(existing.AssetPath(name))
+		if err != nil {
+			continue // already gone
+		}
+		if keepEdits && hasLocalAssetChanges(existing, name, current) {
+			kept++
+			continue
+		}
+		tx.Remove(existing.AssetPath(name))
+	}
+	return kept, nil
+}
+
+// restoreMissingAssets stages the supporting files of an unchanged package that are missing on disk
+func restoreMissingAssets(existing *state.Installation, file embedpkg.CategoryFile, tx *Transaction) (int, error) {
+	restored := 0
+	for name, content := range file.Assets {
+		target := existing.AssetPath(name)
+		if _, err := os.Stat(target); !os.IsNotExist(err) {
+			continue
+		}
+		if err := tx.WriteMode(target, content, assetMode(content)); err != nil {
+			return restored, err
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+// hasLocalAssetChanges reports whether a supporting file was edited since it was installed
+// Files the installation did not record are not considered edited
+func hasLocalAssetChanges(inst *state.Installation, name string, current []byte) bool {
+	if _, ok := inst.Assets[name]; !ok {
+		return false
+	}
+	return inst.HasAssetChanged(name, current)
+}
+
+// assetMode returns the permissions of a supporting file; scripts starting with #! are executable
+func assetMode(content []byte) os.FileMode {
+	if bytes.HasPrefix(content, []byte("#!")) {
+		return 0755
+	}
+	return 0644
+}
@@ -0,0 +1,201 @@
+package installer
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// multiSelectModel extends menuModel with a checkbox per option
+// Header options, when set, start a group: toggling one toggles the options under it
+type multiSelectModel struct {
+	menuModel
+	checked []bool
+	headers []bool // whether each option is a group header
+}
+
+// OptionGroup is a titled group of options in a grouped checklist
+type OptionGroup struct {
+	Title   string
+	Options []string
+}
+
+// Update implements tea.Model
+func (m multiSelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case " ", "x":
+			// Toggle the item under the cursor, or the whole group under a header
+			if m.isHeader(m.selected) {
+				m.toggleAll(m.selected+1, m.groupEnd(m.selected))
+			} else {
+				m.checked[m.selected] = !m.checked[m.selected]
+			}
+			return m, nil
+		case "a":
+			// Toggle all: check everything unless everything is already checked
+			m.toggleAll(0, len(m.options))
+			return m, nil
+		}
+	}
+
+	// Navigation, Enter and Esc behave exactly like the single-select menu
+	updated, cmd := m.menuModel.Update(msg)
+	m.menuModel = updated.(menuModel)
+	return m, cmd
+}
+
+// toggleAll checks the options in [start, end) unless they are all checked already, then clears them
+func (m multiSelectModel) toggleAll(start, end int) {
+	allChecked := m.allChecked(start, end)
+	for i := start; i < end; i++ {
+		if !m.isHeader(i) {
+			m.checked[i] = !allChecked
+		}
+	}
+}
+
+// allChecked reports whether every option in [start, end) is checked
+func (m multiSelectModel) allChecked(start, end int) bool {
+	for i := start; i < end; i++ {
+		if !m.isHeader(i) && !m.checked[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isHeader reports whether an option is a group header
+func (m multiSelectModel) isHeader(i int) bool {
+	return len(m.headers) > 0 && m.headers[i]
+}
+
+// groupEnd returns the index after the last option of the group a header starts
+func (m multiSelectModel) groupEnd(header int) int {
+	end := header + 1
+	for end < len(m.options) && !m.isHeader(end) {
+		end++
+	}
+	return end
+}
+
+// View implements tea.Model
+func (m multiSelectModel) View() string {
+	view := m.menuModel
+	view.options = make([]string, len(m.options))
+	for i, option := range m.options {
+		box := "[ ]"
+		switch {
+		case m.isHeader(i):
+			end := m.groupEnd(i)
+			if m.allChecked(i+1, end) {
+				box = "[x]"
+			} else if slices.Contains(m.checked[i+1:end], true) {
+				box = "[-]"
+			}
+		case m.checked[i]:
+			box = "[x]"
+		}
+		if len(m.headers) > 0 && !m.headers[i] {
+			box = "  " + box // options are indented under their group
+		}
+		view.options[i] = box + " " + option
+	}
+
+	return view.View()
+}
+
+// SelectMultiple displays a checklist and returns the indices of the checked options
+// An empty result means nothing was checked; cancelling returns an error
+func SelectMultiple(prompt string, options []string, initiallyChecked bool) ([]int, error) {
+	checked := make([]bool, len(options))
+	for i := range checked {
+		checked[i] = initiallyChecked
+	}
+
+	m := multiSelectModel{
+		menuModel: menuModel{
+			prompt:     prompt,
+			options:    options,
+			selected:   0,
+			canceled:   false,
+			showBanner: true,
+			help:       "Navigate: ↑/↓  Toggle: Space  All: a  Confirm: Enter (↵)  Back: Esc",
+		},
+		checked: checked,
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	finalModel, err := p.Run()
+	if err != nil {
+		return nil, fmt.Errorf("error running menu: %w", err)
+	}
+
+	result := finalModel.(multiSelectModel)
+	if result.canceled {
+		return nil, fmt.Errorf("cancelled by user")
+	}
+
+	var indices []int
+	for i, isChecked := range result.checked {
+		if isChecked {
+			indices = append(indices, i)
+		}
+	}
+	return indices, nil
+}
+
+// SelectGrouped displays a checklist of options under group headers and returns the indices
+// of the checked options of each group; toggling a header checks or clears its whole group
+func SelectGrouped(prompt string, groups []OptionGroup, initiallyChecked bool) ([][]int, error) {
+	var options []string
+	var checked, headers []bool
+	for _, group := range groups {
+		options = append(options, fmt.Sprintf("%s (%d)", group.Title, len(group.Options)))
+		checked = append(checked, false)
+		headers = append(headers, true)
+		for _, option := range group.Options {
+			options = append(options, option)
+			checked = append(checked, initiallyChecked)
+			headers = append(headers, false)
+		}
+	}
+
+	m := multiSelectModel{
+		menuModel: menuModel{
+			prompt:     prompt,
+			options:    options,
+			selected:   0,
+			canceled:   false,
+			showBanner: true,
+			help:       "Navigate: ↑/↓  Toggle: Space (on a group: all of it)  All: a  Confirm: Enter (↵)  Back: Esc",
+		},
+		checked: checked,
+		headers: headers,
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	finalModel, err := p.Run()
+	if err != nil {
+		return nil, fmt.Errorf("error running menu: %w", err)
+	}
+
+	result := finalModel.(multiSelectModel)
+	if result.canceled {
+		return nil, fmt.Errorf("cancelled by user")
+	}
+
+	selected := make([][]int, len(groups))
+	row := 0
+	for g, group := range groups {
+		row++ // header
+		for i := range group.Options {
+			if result.checked[row] {
+				selected[g] = append(selected[g], i)
+			}
+			row++
+		}
+	}
+	return selected, nil
+}
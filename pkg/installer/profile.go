@@ -0,0 +1,175 @@
+package installer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+// ProfileSelectorPrefix marks a profile in a selector or category menu choice: profile:<name>
+const ProfileSelectorPrefix = "profile:"
+
+// ListProfileFiles returns the catalog files of a profile
+func ListProfileFiles(name string) ([]embedpkg.CategoryFile, error) {
+	profile, err := embedpkg.GetProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := profile.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("profile '%s' has no files", name)
+	}
+	return files, nil
+}
+
+// InstallProfile installs every file of a profile, with the files they require, as one unit
+// The profile is recorded on the files it brings in; files that were already installed
+// outside any profile stay untagged, so removing the profile leaves them in place
+func InstallProfile(name string) error {
+	files, err := ListProfileFiles(name)
+	if err != nil {
+		return err
+	}
+
+	st, unlock, err := lockState(CurrentInstallMode)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	plan, err := PlanInstall(st, files)
+	if err != nil {
+		return err
+	}
+
+	untagged := make(map[string]bool)
+	for _, file := range plan.Files {
+		if inst := findInstalled(st, file.Source, file.Category, file.Type, file.Filename); inst != nil && len(inst.Profiles) == 0 {
+			untagged[inst.InstalledPath] = true
+		}
+	}
+
+	ShowBanner()
+	fmt.Printf("Installing profile: %s [%s]\n", name, GetInstallModeDescription())
+
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+
+	installed, refused, err := installPlanned(plan.Files, tx, func(file embedpkg.CategoryFile, tx *Transaction) error {
+		if err := InstallFile(file, tx); err != nil {
+			return err
+		}
+		if installedPath := installedPathOf(st, file); !untagged[installedPath] {
+			st.AddProfile(installedPath, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := commitChanges(tx, installed, nil); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✓ Successfully installed %d files from profile '%s' [%s]\n", len(installed), name, GetInstallModeDescription())
+	return refusedError(refused)
+}
+
+// RemoveProfile removes the files a profile brought in
+// Files another profile also brought in are kept and only lose this profile; the
+// profile does not need to be defined anymore, since its files are found by their tag
+func RemoveProfile(name string) error {
+	st, unlock, err := lockState(CurrentInstallMode)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	removing, kept := profileInstallations(st, name)
+	if len(removing) == 0 && len(kept) == 0 {
+		// No files to remove - skip silently
+		return nil
+	}
+
+	ShowBanner()
+	fmt.Printf("Removing profile: %s [%s]\n", name, GetInstallModeDescription())
+	warnBrokenDependents(st, removing)
+
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+
+	for _, inst := range removing {
+		RemoveInstallation(inst, tx)
+	}
+	for _, inst := range kept {
+		st.RemoveProfile(inst.InstalledPath, name)
+		fmt.Printf("  ✓ %s: %s (kept, %s)\n", strings.TrimSuffix(inst.Type, "s"), shortenHome(inst.InstalledPath), otherProfiles(inst, name))
+	}
+
+	if err := commitChanges(tx, nil, removing); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✓ Successfully removed profile '%s' (%d files removed, %d kept) [%s]\n", name, len(removing), len(kept), GetInstallModeDescription())
+	return nil
+}
+
+// profileInstallations splits the installations a profile brought in into the ones
+// only it brought in and the ones another profile also brought in
+func profileInstallations(st *state.State, name string) (removing, kept []state.Installation) {
+	for _, inst := range st.ListProfileInstallations(name) {
+		if len(inst.Profiles) > 1 {
+			kept = append(kept, inst)
+		} else {
+			removing = append(removing, inst)
+		}
+	}
+	return removing, kept
+}
+
+// otherProfiles describes the profiles other than name that brought an installation in
+func otherProfiles(inst state.Installation, name string) string {
+	others := slices.DeleteFunc(slices.Clone(inst.Profiles), func(p string) bool { return p == name })
+	if len(others) == 1 {
+		return "also in profile " + others[0]
+	}
+	return "also in profiles " + strings.Join(others, ", ")
+}
+
+// PreviewInstallProfile shows what installing a profile will change and asks for confirmation
+func PreviewInstallProfile(name string) (bool, error) {
+	files, err := ListProfileFiles(name)
+	if err != nil {
+		return false, err
+	}
+
+	return confirmInstallPreview(Selector{Profile: name}.String(), files)
+}
+
+// PreviewRemoveProfile shows what removing a profile will change and asks for confirmation
+func PreviewRemoveProfile(name string) (bool, error) {
+	sel := Selector{Profile: name}
+
+	installations, err := ListSelectedInstallations(sel)
+	if err != nil {
+		return false, err
+	}
+	if len(installations) == 0 {
+		// No files to remove - skip preview and return true to continue
+		return true, nil
+	}
+
+	if err := PrintRemovePreview(sel); err != nil {
+		return false, err
+	}
+
+	return confirmInline("Proceed with removal?", "Yes, remove")
+}
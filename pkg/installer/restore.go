@@ -0,0 +1,111 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+// RestoreChoices reports how an installation whose files are missing or corrupted can be restored
+// fromCatalog is false when the catalog no longer has the file; asInstalled is only true when the
+// catalog has changed the file since it was installed and its installed content can still be written back
+func RestoreChoices(claudeDir string, inst state.Installation) (fromCatalog, asInstalled bool) {
+	file, err := embedpkg.GetSourceFile(inst.Source, inst.Category, inst.Type, inst.File)
+	fromCatalog = err == nil
+	if fromCatalog && !packageChanged(&inst, *file) {
+		return true, false
+	}
+
+	if _, err := inst.LoadInstalled(claudeDir); err != nil {
+		return fromCatalog, false
+	}
+	for name := range inst.Assets {
+		if _, err := os.Stat(inst.AssetPath(name)); err == nil {
+			continue
+		}
+		if !fromCatalog || !hasAsset(&inst, name, *file) {
+			return fromCatalog, false // only the catalog could have supplied it
+		}
+	}
+	return fromCatalog, true
+}
+
+// Restore writes the files of an installation in the current install mode back to disk
+// From the catalog the file is reinstalled with the current catalog version, as an update would;
+// asInstalled writes back the exact content it was installed with and leaves the state as it is
+func Restore(installedPath string, asInstalled bool) error {
+	st, unlock, err := lockState(CurrentInstallMode)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	inst := st.FindInstallation(installedPath)
+	if inst == nil {
+		return fmt.Errorf("%s is not managed by cc-foundry", shortenHome(installedPath))
+	}
+
+	tx := BeginTransaction(st)
+	defer tx.Rollback()
+
+	file, catalogErr := embedpkg.GetSourceFile(inst.Source, inst.Category, inst.Type, inst.File)
+	if asInstalled {
+		if err := restoreInstalled(inst, file, tx); err != nil {
+			return err
+		}
+		return commitChanges(tx, nil, nil)
+	}
+
+	if catalogErr != nil {
+		return fmt.Errorf("cannot restore %s: %s is no longer in the catalog", shortenHome(installedPath), embedpkg.Reference(inst.Category, inst.Type, inst.File))
+	}
+	rendered, err := installContent(*file)
+	if err != nil {
+		return err
+	}
+	if err := tx.Write(installedPath, rendered); err != nil {
+		return err
+	}
+	if _, err := stageAssets(inst, installedPath, *file, tx); err != nil {
+		return err
+	}
+	if err := recordInstallation(st, *file, installedPath, rendered, inst.NamePrefix, inst.Profiles); err != nil {
+		return err
+	}
+	return commitChanges(tx, []embedpkg.CategoryFile{*file}, nil)
+}
+
+// restoreInstalled stages the content an installation was installed with
+// Supporting files are not kept with the originals, so missing ones come from the catalog
+// file, which must still have them unchanged; file is nil when the catalog no longer has it
+func restoreInstalled(inst *state.Installation, file *embedpkg.CategoryFile, tx *Transaction) error {
+	content, err := inst.LoadInstalled(tx.State().Dir())
+	if err != nil {
+		return fmt.Errorf("failed to load the installed content of %s: %w", shortenHome(inst.InstalledPath), err)
+	}
+	if err := tx.Write(inst.InstalledPath, content); err != nil {
+		return err
+	}
+
+	for name := range inst.Assets {
+		target := inst.AssetPath(name)
+		if _, err := os.Stat(target); err == nil {
+			continue
+		}
+		if file == nil || !hasAsset(inst, name, *file) {
+			return fmt.Errorf("cannot restore %s: the catalog has changed it since it was installed", shortenHome(target))
+		}
+		if err := tx.WriteMode(target, file.Assets[name], assetMode(file.Assets[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasAsset reports whether a catalog file still has a supporting file as it was installed
+func hasAsset(inst *state.Installation, name string, file embedpkg.CategoryFile) bool {
+	content, ok := file.Assets[name]
+	return ok && !inst.HasAssetChanged(name, content)
+}
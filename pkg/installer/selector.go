@@ -0,0 +1,190 @@
+package installer
+
+import (
+	"fmt"
+	"strings"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+// Selector identifies a set of catalog files: everything, a category,
+// a type within a category, a single file, or a profile
+type Selector struct {
+	Category string // empty means all categories
+	Type     string // "commands", "agents", "skills", or empty for all types
+	File     string // filename including .md extension, or empty for all files
+	Profile  string // profile name; the other fields are empty when it is set
+}
+
+// ParseSelector parses a selector of the form <category>[/<type>[/<file>]] or profile:<name>
+// "all" (or an empty string) selects every category
+func ParseSelector(s string) (Selector, error) {
+	s = strings.Trim(strings.TrimSpace(s), "/")
+	if s == "" || s == "all" {
+		return Selector{}, nil
+	}
+
+	if name, ok := strings.CutPrefix(s, ProfileSelectorPrefix); ok {
+		if name == "" {
+			return Selector{}, fmt.Errorf("invalid selector '%s' (expected %s<name>)", s, ProfileSelectorPrefix)
+		}
+		return Selector{Profile: name}, nil
+	}
+
+	parts := strings.Split(s, "/")
+	if len(parts) > 3 {
+		return Selector{}, fmt.Errorf("invalid selector '%s' (expected <category>[/<type>[/<file>]])", s)
+	}
+
+	for _, part := range parts {
+		if part == "" {
+			return Selector{}, fmt.Errorf("invalid selector '%s' (empty path segment)", s)
+		}
+	}
+
+	sel := Selector{Category: parts[0]}
+
+	if len(parts) >= 2 {
+		switch parts[1] {
+		case "commands", "agents", "skills":
+			sel.Type = parts[1]
+		default:
+			return Selector{}, fmt.Errorf("invalid type '%s' (expected commands, agents, or skills)", parts[1])
+		}
+	}
+
+	if len(parts) == 3 {
+		sel.File = parts[2]
+		if !strings.HasSuffix(sel.File, ".md") {
+			sel.File += ".md"
+		}
+	}
+
+	return sel, nil
+}
+
+// IsAll reports whether the selector covers every category
+func (s Selector) IsAll() bool {
+	return s.Category == "" && s.Profile == ""
+}
+
+// String returns the selector in <category>[/<type>[/<file>]] form, or as "profile <name>"
+func (s Selector) String() string {
+	if s.Profile != "" {
+		return "profile " + s.Profile
+	}
+	if s.IsAll() {
+		return "all categories"
+	}
+
+	parts := []string{s.Category}
+	if s.Type != "" {
+		parts = append(parts, s.Type)
+	}
+	if s.File != "" {
+		parts = append(parts, s.File)
+	}
+	return strings.Join(parts, "/")
+}
+
+// ListSelectedFiles returns the catalog files matched by a selector
+func ListSelectedFiles(sel Selector) ([]embedpkg.CategoryFile, error) {
+	if sel.Profile != "" {
+		return ListProfileFiles(sel.Profile)
+	}
+
+	var files []embedpkg.CategoryFile
+	var err error
+
+	switch {
+	case sel.IsAll():
+		files, err = embedpkg.ListAllFiles()
+	case sel.File != "":
+		var file *embedpkg.CategoryFile
+		file, err = embedpkg.GetFile(sel.Category, sel.Type, sel.File)
+		if err != nil {
+			return nil, fmt.Errorf("file '%s' not found", sel)
+		}
+		files = []embedpkg.CategoryFile{*file}
+	case sel.Type != "":
+		files, err = embedpkg.ListTypeFiles(sel.Category, sel.Type)
+	default:
+		files, err = embedpkg.ListCategoryFiles(sel.Category)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	if len(files) == 0 {
+		if sel.IsAll() {
+			return nil, fmt.Errorf("no installable files found")
+		}
+		if sel.Type != "" {
+			return nil, fmt.Errorf("no %s found in category '%s'", sel.Type, sel.Category)
+		}
+		return nil, fmt.Errorf("no files found in category '%s'", sel.Category)
+	}
+
+	return files, nil
+}
+
+// InstallSelection installs every file matched by a selector
+func InstallSelection(sel Selector) error {
+	switch {
+	case sel.Profile != "":
+		return InstallProfile(sel.Profile)
+	case sel.IsAll():
+		return InstallCategory("")
+	case sel.File != "":
+		return InstallSingleFile(sel.Category, sel.Type, sel.File)
+	case sel.Type != "":
+		return InstallType(sel.Category, sel.Type)
+	default:
+		return InstallCategory(sel.Category)
+	}
+}
+
+// ListSelectedInstallations returns the installations in the current mode matched by a selector
+func ListSelectedInstallations(sel Selector) ([]state.Installation, error) {
+	st, err := LoadState(CurrentInstallMode)
+	if err != nil {
+		return nil, err
+	}
+
+	if sel.Profile != "" {
+		return st.ListProfileInstallations(sel.Profile), nil
+	}
+	return filterInstallationsByFile(st.ListInstallations(sel.Category, sel.Type), sel.File), nil
+}
+
+// RemoveSelection removes every installed file matched by a selector
+func RemoveSelection(sel Selector) error {
+	switch {
+	case sel.Profile != "":
+		return RemoveProfile(sel.Profile)
+	case sel.IsAll():
+		return RemoveAll()
+	case sel.File != "":
+		return RemoveSingleFile(sel.Category, sel.Type, sel.File)
+	case sel.Type != "":
+		return RemoveType(sel.Category, sel.Type)
+	default:
+		return RemoveCategory(sel.Category)
+	}
+}
+
+// filterInstallationsByFile keeps installations of the given catalog file (empty keeps all)
+func filterInstallationsByFile(installations []state.Installation, filename string) []state.Installation {
+	if filename == "" {
+		return installations
+	}
+
+	var filtered []state.Installation
+	for _, inst := range installations {
+		if inst.File == filename {
+			filtered = append(filtered, inst)
+		}
+	}
+	return filtered
+}
@@ -354,28 +354,19 @@ func buildDirNode(label, dirPath string, isSkillsDir bool, depth int) (*treeNode
 
 // buildInstalledFilesNode builds a tree node for installed files grouped by category
 func buildInstalledFilesNode() (*treeNode, error) {
-	st, err := state.Load()
+	userLevelInsts, projectLevelInsts, err := loadAllInstallations()
 	if err != nil {
 		return nil, err
 	}
 
-	if len(st.Installations) == 0 {
+	allInsts := append(append([]state.Installation{}, userLevelInsts...), projectLevelInsts...)
+	if len(allInsts) == 0 {
 		return nil, nil
 	}
 
-	// Separate installations by location
-	home, _ := os.UserHomeDir()
-	cwd, _ := os.Getwd()
-
-	var userLevelInsts []state.Installation
-	var projectLevelInsts []state.Installation
-
-	for _, inst := range st.Installations {
-		if strings.HasPrefix(inst.InstalledPath, filepath.Join(home, ".claude")) {
-			userLevelInsts = append(userLevelInsts, inst)
-		} else if strings.HasPrefix(inst.InstalledPath, filepath.Join(cwd, ".claude")) {
-			projectLevelInsts = append(projectLevelInsts, inst)
-		}
+	userPaths := make(map[string]bool)
+	for _, inst := range userLevelInsts {
+		userPaths[inst.InstalledPath] = true
 	}
 
 	userCount := len(userLevelInsts)
@@ -392,7 +383,7 @@ func buildInstalledFilesNode() (*treeNode, error) {
 
 	// Group installations by category (combining both locations)
 	byCategory := make(map[string][]state.Installation)
-	for _, inst := range st.Installations {
+	for _, inst := range allInsts {
 		byCategory[inst.Category] = append(byCategory[inst.Category], inst)
 	}
 
@@ -465,10 +456,8 @@ func buildInstalledFilesNode() (*treeNode, error) {
 
 				// Determine location icon
 				locationIcon := "🏠"
-				if home, err := os.UserHomeDir(); err == nil {
-					if !strings.HasPrefix(inst.InstalledPath, home) {
-						locationIcon = "📂"
-					}
+				if !userPaths[inst.InstalledPath] {
+					locationIcon = "📂"
 				}
 
 				fileNode := &treeNode{
@@ -575,19 +564,20 @@ func countFiles(dirPath string, isSkillsDir bool) (int, error) {
 
 // appendInstalledFiles appends installed files grouped by category to string builder
 func appendInstalledFiles(sb *strings.Builder) error {
-	st, err := state.Load()
+	userLevelInsts, projectLevelInsts, err := loadAllInstallations()
 	if err != nil {
-		return fmt.Errorf("failed to load state: %w", err)
+		return err
 	}
 
-	if len(st.Installations) == 0 {
+	allInsts := append(userLevelInsts, projectLevelInsts...)
+	if len(allInsts) == 0 {
 		sb.WriteString("  No files installed by foundry yet\n")
 		return nil
 	}
 
 	// Group installations by category
 	byCategory := make(map[string][]state.Installation)
-	for _, inst := range st.Installations {
+	for _, inst := range allInsts {
 		byCategory[inst.Category] = append(byCategory[inst.Category], inst)
 	}
 
@@ -614,7 +604,7 @@ func appendInstalledFiles(sb *strings.Builder) error {
 		sb.WriteString(fmt.Sprintf("  %s: %s\n", category, strings.Join(countParts, ", ")))
 	}
 
-	sb.WriteString(fmt.Sprintf("\n  Total: %d file%s installed\n", len(st.Installations), plural(len(st.Installations))))
+	sb.WriteString(fmt.Sprintf("\n  Total: %d file%s installed\n", len(allInsts), plural(len(allInsts))))
 
 	return nil
 }
@@ -626,3 +616,18 @@ func plural(count int) string {
 	}
 	return "s"
 }
+
+// loadAllInstallations loads the installations recorded in both the user and project state files
+func loadAllInstallations() ([]state.Installation, []state.Installation, error) {
+	userState, err := LoadState(InstallModeUser)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	projectState, err := LoadState(InstallModeProject)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return userState.Installations, projectState.Installations, nil
+}
@@ -14,6 +14,7 @@ var (
 	// Status colors
 	colorSuccess = lipgloss.Color("42")  // Green
 	colorWarning = lipgloss.Color("226") // Yellow
+	colorError   = lipgloss.Color("203") // Red
 	colorMuted   = lipgloss.Color("241") // Gray
 
 	// UI colors
@@ -71,6 +72,19 @@ var promptStyle = lipgloss.NewStyle().
 	Foreground(colorPrimary).
 	Bold(true)
 
+// Diff styles - added, removed, hunk header, and file header lines
+var diffAddStyle = lipgloss.NewStyle().
+	Foreground(colorSuccess)
+
+var diffRemoveStyle = lipgloss.NewStyle().
+	Foreground(colorError)
+
+var diffHunkStyle = lipgloss.NewStyle().
+	Foreground(colorAccent)
+
+var diffHeaderStyle = lipgloss.NewStyle().
+	Bold(true)
+
 // Banner style - for ASCII art header
 var bannerStyle = lipgloss.NewStyle().
 	Foreground(colorPrimary).
@@ -0,0 +1,117 @@
+package installer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+)
+
+// TemplateVars are the values a catalog file with template: true in its frontmatter can use
+// They describe the project in the current directory; values that cannot be found are empty
+type TemplateVars struct {
+	ProjectName string // name of the project directory
+	ModulePath  string // module path from go.mod
+	GitOwner    string // owner of the origin remote, e.g. shapestone
+	GitRepo     string // repository of the origin remote, e.g. cc-foundry
+	License     string // SPDX identifier of the LICENSE file, e.g. Apache-2.0
+}
+
+// licensePatterns maps the opening text of common license files to their SPDX identifiers
+var licensePatterns = []struct {
+	pattern *regexp.Regexp
+	spdx    string
+}{
+	{regexp.MustCompile(`(?i)apache license\s+version 2\.0`), "Apache-2.0"},
+	{regexp.MustCompile(`(?i)\bmit license\b`), "MIT"},
+	{regexp.MustCompile(`(?i)gnu affero general public license\s+version 3`), "AGPL-3.0"},
+	{regexp.MustCompile(`(?i)gnu lesser general public license\s+version 3`), "LGPL-3.0"},
+	{regexp.MustCompile(`(?i)gnu general public license\s+version 3`), "GPL-3.0"},
+	{regexp.MustCompile(`(?i)gnu general public license\s+version 2`), "GPL-2.0"},
+	{regexp.MustCompile(`(?i)mozilla public license,? (version|v\.) 2\.0`), "MPL-2.0"},
+	{regexp.MustCompile(`(?i)redistribution and use .* neither the name`), "BSD-3-Clause"},
+	{regexp.MustCompile(`(?i)redistribution and use in source and binary forms`), "BSD-2-Clause"},
+	{regexp.MustCompile(`(?i)this is free and unencumbered software`), "Unlicense"},
+}
+
+// remotePattern extracts owner and repository from scp-style and URL git remotes
+var remotePattern = regexp.MustCompile(`[:/]([^/:]+)/([^/]+?)(?:\.git)?/?$`)
+
+// ResolveTemplateVars reads the template variables of the project in dir
+func ResolveTemplateVars(dir string) TemplateVars {
+	vars := TemplateVars{ProjectName: filepath.Base(dir)}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+				vars.ModulePath = strings.Trim(strings.TrimSpace(module), `"`)
+				break
+			}
+		}
+	}
+
+	cmd := exec.Command("git", "config", "--get", "remote.origin.url")
+	cmd.Dir = dir
+	if output, err := cmd.Output(); err == nil {
+		if match := remotePattern.FindStringSubmatch(strings.TrimSpace(string(output))); match != nil {
+			vars.GitOwner, vars.GitRepo = match[1], match[2]
+			vars.ProjectName = match[2]
+		}
+	}
+
+	for _, name := range []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "COPYING"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		text := strings.Join(strings.Fields(string(data)), " ")
+		for _, license := range licensePatterns {
+			if license.pattern.MatchString(text) {
+				vars.License = license.spdx
+				break
+			}
+		}
+		break
+	}
+
+	return vars
+}
+
+// installContent returns the content a catalog file is installed with
+// Files with template: true are rendered with the variables of the project in the current directory;
+// user installs are shared by every project, so they are rendered with empty variables instead
+func installContent(file embedpkg.CategoryFile) ([]byte, error) {
+	if !file.Metadata.Template {
+		return file.Content, nil
+	}
+	if CurrentInstallMode != InstallModeProject {
+		return renderTemplate(file, TemplateVars{})
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return renderTemplate(file, ResolveTemplateVars(dir))
+}
+
+// renderTemplate executes a catalog file as a text/template with vars
+func renderTemplate(file embedpkg.CategoryFile, vars TemplateVars) ([]byte, error) {
+	name := embedpkg.Reference(file.Category, file.Type, file.Filename)
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(file.Content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,289 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+// BackupDirName is the directory under $HOME holding the backups of a running transaction
+const BackupDirName = ".cc-foundry-backups"
+
+// Transaction stages the file changes of one install or remove operation
+// Nothing under .claude/ changes until Commit, which applies every staged write
+// and removal and then saves the state. If any step fails, every file touched
+// so far is restored from its backup, so disk and state never disagree
+type Transaction struct {
+	st       *state.State
+	writes   []stagedWrite
+	removals []string
+	guarded  []string // files rewritten by the save step (state, lockfile)
+	created  []string // directories created while staging
+
+	backupDir   string
+	backups     []fileBackup
+	keepBackups bool
+	finished    bool
+}
+
+// stagedWrite is new content waiting in a temp file next to its destination
+type stagedWrite struct {
+	path string
+	tmp  string
+}
+
+// fileBackup records the previous content of a path; saved is empty if the path did not exist
+type fileBackup struct {
+	path  string
+	saved string
+}
+
+// BeginTransaction starts a transaction that records its changes in st
+func BeginTransaction(st *state.State) *Transaction {
+	return &Transaction{st: st}
+}
+
+// State returns the state the transaction's changes are recorded in
+func (tx *Transaction) State() *state.State {
+	return tx.st
+}
+
+// Write stages new content for a file
+// The content goes to a hidden temp file in the destination directory, so Commit only has to rename it
+func (tx *Transaction) Write(path string, content []byte) error {
+	return tx.WriteMode(path, content, 0644)
+}
+
+// WriteMode stages new content for a file with the given permissions
+func (tx *Transaction) WriteMode(path string, content []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := tx.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to stage file %s: %w", path, err)
+	}
+	tx.writes = append(tx.writes, stagedWrite{path: path, tmp: tmp.Name()})
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to stage file %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to stage file %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to stage file %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("failed to stage file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Remove stages the removal of a file or directory
+func (tx *Transaction) Remove(path string) {
+	tx.removals = append(tx.removals, path)
+}
+
+// Guard backs up a file that the save step of Commit rewrites, so it is restored on failure too
+func (tx *Transaction) Guard(path string) {
+	tx.guarded = append(tx.guarded, path)
+}
+
+// Commit applies every staged change, then calls save to persist the state
+// On failure everything is rolled back and the returned error says so
+func (tx *Transaction) Commit(save func() error) error {
+	if tx.finished {
+		return fmt.Errorf("transaction already finished")
+	}
+	defer tx.cleanup()
+
+	for _, w := range tx.writes {
+		if err := tx.backup(w.path); err != nil {
+			return tx.fail(err)
+		}
+		if err := os.Rename(w.tmp, w.path); err != nil {
+			return tx.fail(fmt.Errorf("failed to write file %s: %w", w.path, err))
+		}
+	}
+
+	for _, path := range tx.removals {
+		if err := tx.backup(path); err != nil {
+			return tx.fail(err)
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return tx.fail(fmt.Errorf("failed to remove %s: %w", path, err))
+		}
+	}
+
+	for _, path := range tx.guarded {
+		if err := tx.backup(path); err != nil {
+			return tx.fail(err)
+		}
+	}
+
+	if err := save(); err != nil {
+		return tx.fail(err)
+	}
+
+	tx.finished = true
+	return nil
+}
+
+// Rollback discards staged changes that were never committed
+// It is a no-op after Commit, so it can always be deferred
+func (tx *Transaction) Rollback() {
+	tx.cleanup()
+}
+
+// fail restores every backed-up path in reverse order
+func (tx *Transaction) fail(cause error) error {
+	var errs []error
+	for i := len(tx.backups) - 1; i >= 0; i-- {
+		b := tx.backups[i]
+		if err := os.RemoveAll(b.path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if b.saved != "" {
+			if err := copyPath(b.saved, b.path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		tx.keepBackups = true
+		return fmt.Errorf("%w (rollback incomplete, previous files kept in %s: %v)", cause, tx.backupDir, errors.Join(errs...))
+	}
+	return fmt.Errorf("%w (all changes rolled back)", cause)
+}
+
+// backup copies the current content of a path into the transaction's backup directory
+func (tx *Transaction) backup(path string) error {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		tx.backups = append(tx.backups, fileBackup{path: path})
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+
+	if tx.backupDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory: %w", err)
+		}
+		root := filepath.Join(home, BackupDirName)
+		if err := os.MkdirAll(root, 0755); err != nil {
+			return fmt.Errorf("failed to create backup directory %s: %w", root, err)
+		}
+		dir, err := os.MkdirTemp(root, time.Now().Format("20060102-150405")+"-")
+		if err != nil {
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		tx.backupDir = dir
+	}
+
+	saved := filepath.Join(tx.backupDir, strconv.Itoa(len(tx.backups)))
+	if err := copyPath(path, saved); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	tx.backups = append(tx.backups, fileBackup{path: path, saved: saved})
+	return nil
+}
+
+// mkdirAll creates a directory and its parents, remembering the ones that did not exist
+func (tx *Transaction) mkdirAll(dir string) error {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		tx.created = append(tx.created, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// cleanup removes leftover temp files, directories created for changes that
+// never landed, and the backups once they are no longer needed
+func (tx *Transaction) cleanup() {
+	for _, w := range tx.writes {
+		os.Remove(w.tmp)
+	}
+
+	if !tx.finished {
+		// Deepest first; only empty directories are removed
+		sort.Slice(tx.created, func(i, j int) bool { return len(tx.created[i]) > len(tx.created[j]) })
+		for _, dir := range tx.created {
+			os.Remove(dir)
+		}
+	}
+	tx.created = nil
+
+	if tx.backupDir != "" && !tx.keepBackups {
+		os.RemoveAll(tx.backupDir)
+	}
+	tx.finished = true
+}
+
+// copyPath copies a file or directory tree, preserving file modes
+func copyPath(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		return copyFile(path, target, info.Mode().Perm())
+	})
+}
+
+// copyFile copies a single file
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,109 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/shapestone/cc-foundry/pkg/diff"
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+// UpdateStrategy decides what happens when an update targets a file the user has edited
+type UpdateStrategy int
+
+const (
+	UpdateAsk         UpdateStrategy = iota // prompt interactively; keep local edits in headless mode
+	UpdateKeepLocal                         // leave the edited file alone
+	UpdateTakeCatalog                       // overwrite the edits with the catalog version
+	UpdateMerge                             // three-way merge against the originally installed content
+)
+
+// CurrentUpdateStrategy is the active strategy for locally modified files (default: ask)
+var CurrentUpdateStrategy = UpdateAsk
+
+// ParseUpdateStrategy parses a --on-modified value: keep, overwrite, or merge
+func ParseUpdateStrategy(s string) (UpdateStrategy, error) {
+	switch s {
+	case "keep":
+		return UpdateKeepLocal, nil
+	case "overwrite":
+		return UpdateTakeCatalog, nil
+	case "merge":
+		return UpdateMerge, nil
+	default:
+		return UpdateAsk, fmt.Errorf("invalid value '%s' (expected keep, overwrite, or merge)", s)
+	}
+}
+
+// hasLocalChanges reports whether an installed file was edited since it was installed
+func hasLocalChanges(inst *state.Installation) bool {
+	content, err := os.ReadFile(inst.InstalledPath)
+	if err != nil {
+		return false
+	}
+	return inst.IsModified(content)
+}
+
+// resolveUpdate decides the content to write when updating an installed file
+// catalog is the new content as it would be written, after rendering a template
+// A nil result means the local file is kept as is; note describes what happened
+func resolveUpdate(existing *state.Installation, claudeDir, displayName string, catalog []byte) (content []byte, note string, err error) {
+	local, err := os.ReadFile(existing.InstalledPath)
+	if os.IsNotExist(err) {
+		return catalog, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", existing.InstalledPath, err)
+	}
+	if !existing.IsModified(local) {
+		return catalog, "", nil
+	}
+
+	original, originalErr := existing.LoadInstalled(claudeDir)
+	canMerge := originalErr == nil
+
+	strategy := CurrentUpdateStrategy
+	if strategy == UpdateAsk {
+		if Headless {
+			return nil, "kept local changes, use --on-modified to choose", nil
+		}
+		strategy, err = promptUpdateStrategy(displayName, canMerge)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	switch strategy {
+	case UpdateTakeCatalog:
+		return catalog, "local changes overwritten", nil
+	case UpdateMerge:
+		if !canMerge {
+			return nil, "kept local changes, original content unknown so cannot merge", nil
+		}
+		merged, conflicts := diff.Merge3(original, local, catalog)
+		if conflicts > 0 {
+			return merged, fmt.Sprintf("merged with %d conflicts, resolve the markers", conflicts), nil
+		}
+		return merged, "merged with local changes", nil
+	default:
+		return nil, "kept local changes", nil
+	}
+}
+
+// promptUpdateStrategy asks what to do with a file that has local changes
+func promptUpdateStrategy(displayName string, canMerge bool) (UpdateStrategy, error) {
+	var options []string
+	var strategies []UpdateStrategy
+	if canMerge {
+		options = append(options, "Merge my changes with the update")
+		strategies = append(strategies, UpdateMerge)
+	}
+	options = append(options, "Keep my version", "Take the catalog version")
+	strategies = append(strategies, UpdateKeepLocal, UpdateTakeCatalog)
+
+	selected, err := SelectOptionInline(fmt.Sprintf("%s has local changes", displayName), options)
+	if err != nil {
+		return UpdateAsk, err
+	}
+	return strategies[selected], nil
+}
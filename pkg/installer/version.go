@@ -0,0 +1,79 @@
+package installer
+
+import (
+	"errors"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+// AllowDowngrade lets an install replace a file with an older catalog version (install --force)
+var AllowDowngrade = false
+
+// ErrDowngradeRefused reports a file left at its newer installed version because AllowDowngrade is off
+var ErrDowngradeRefused = errors.New("downgrade refused")
+
+// OutdatedFile is an installed file whose catalog version is newer than the installed one
+type OutdatedFile struct {
+	Installation state.Installation
+	Installed    string // installed version, empty when unknown
+	Latest       string // catalog version
+}
+
+// installedVersion returns the frontmatter version an installation was installed at
+// Installs recorded before versions were tracked fall back to the frontmatter they were installed with
+func installedVersion(inst *state.Installation, claudeDir string) string {
+	if inst.Version != "" {
+		return inst.Version
+	}
+	return installedMetadata(inst, claudeDir).Version
+}
+
+// isDowngrade reports whether installing a catalog file would replace a newer installed version
+// Files without a version on either side are never treated as downgrades
+func isDowngrade(installed string, file embedpkg.CategoryFile) bool {
+	if installed == "" || file.Metadata.Version == "" {
+		return false
+	}
+	return embedpkg.CompareVersions(file.Metadata.Version, installed) < 0
+}
+
+// ListOutdated returns the installations in the current mode whose catalog version is newer
+// An installation of unknown version counts as outdated when its content differs from a versioned catalog file
+func ListOutdated() ([]OutdatedFile, error) {
+	st, err := LoadState(CurrentInstallMode)
+	if err != nil {
+		return nil, err
+	}
+
+	var outdated []OutdatedFile
+	for _, inst := range st.ListInstallations("", "") {
+		file, err := embedpkg.GetSourceFile(inst.Source, inst.Category, inst.Type, inst.File)
+		if err != nil || file.Metadata.Version == "" {
+			continue // no longer in the catalog, or unversioned
+		}
+
+		installed := installedVersion(&inst, st.Dir())
+		switch {
+		case installed == "" && !inst.HasContentChanged(file.Content):
+			continue
+		case installed != "" && embedpkg.CompareVersions(file.Metadata.Version, installed) <= 0:
+			continue
+		}
+
+		outdated = append(outdated, OutdatedFile{
+			Installation: inst,
+			Installed:    installed,
+			Latest:       file.Metadata.Version,
+		})
+	}
+	return outdated, nil
+}
+
+// versionTransition describes a version change as "v3 → v4", or "" when either version is unknown or they are equal
+func versionTransition(installed, latest string) string {
+	if installed == "" || latest == "" || embedpkg.CompareVersions(installed, latest) == 0 {
+		return ""
+	}
+	return embedpkg.VersionLabel(installed) + " → " + embedpkg.VersionLabel(latest)
+}
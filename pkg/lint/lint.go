@@ -0,0 +1,384 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
+)
+
+// Severity levels
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// MaxFileSize is the largest catalog file accepted; Claude Code loads the whole file into context
+const MaxFileSize = 64 * 1024
+
+// requiredKeys lists the frontmatter keys every file of a type (or profile) must set
+var requiredKeys = map[string][]string{
+	"commands":           {"name", "description"},
+	"agents":             {"name", "description", "tools"},
+	"skills":             {"name", "description"},
+	embedpkg.ProfilesDir: {"name", "description", "files"},
+}
+
+// KnownTools are the Claude Code tool names an agent may list; mcp__ tools are always accepted
+var KnownTools = map[string]bool{
+	"Agent": true, "AskUserQuestion": true, "Bash": true, "BashOutput": true, "Edit": true,
+	"ExitPlanMode": true, "Glob": true, "Grep": true, "KillShell": true, "LS": true,
+	"MultiEdit": true, "NotebookEdit": true, "NotebookRead": true, "Read": true, "Skill": true,
+	"SlashCommand": true, "Task": true, "TodoWrite": true, "WebFetch": true, "WebSearch": true,
+	"Write": true,
+}
+
+// Finding is one problem in a catalog file
+type Finding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+// Report contains the findings of a lint run
+type Report struct {
+	Findings     []Finding `json:"findings"`
+	Errors       int       `json:"errors"`
+	Warnings     int       `json:"warnings"`
+	FilesChecked int       `json:"files_checked"`
+}
+
+// linkPattern matches markdown links and images: [text](target) and ![alt](target "title")
+var linkPattern = regexp.MustCompile(`!?\[[^\]]*\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+
+// yamlLinePattern and yamlFieldPattern locate a frontmatter parse error by line or by key
+var (
+	yamlLinePattern  = regexp.MustCompile(`line (\d+)`)
+	yamlFieldPattern = regexp.MustCompile(`in field "(\w+)"`)
+)
+
+// templateLinePattern locates a template parse error
+var templateLinePattern = regexp.MustCompile(`^template: [^:]+:(\d+):`)
+
+// Run lints a catalog holding categories/<category>/<type>/*.md, or <category>/<type>/*.md at its top level
+// Reported paths are the paths inside fsys joined to root
+func Run(fsys fs.FS, root string) (*Report, error) {
+	report := &Report{Findings: []Finding{}}
+
+	base := "."
+	if info, err := fs.Stat(fsys, embedpkg.CatalogDir); err == nil && info.IsDir() {
+		base = embedpkg.CatalogDir
+	}
+
+	categories, err := fs.ReadDir(fsys, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+
+	for _, category := range categories {
+		if !category.IsDir() {
+			continue // READMEs and other files next to the categories
+		}
+		if err := lintCategory(fsys, root, path.Join(base, category.Name()), report); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		if report.Findings[i].File != report.Findings[j].File {
+			return report.Findings[i].File < report.Findings[j].File
+		}
+		return report.Findings[i].Line < report.Findings[j].Line
+	})
+	return report, nil
+}
+
+// lintCategory checks the structure of one category and every file in it
+func lintCategory(fsys fs.FS, root, dir string, report *Report) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	names := make(map[string]string) // frontmatter name -> first file using it
+	for _, entry := range entries {
+		entryPath := path.Join(dir, entry.Name())
+		if !entry.IsDir() {
+			report.add(path.Join(root, entryPath), 0, SeverityWarning, "structure", "file outside a commands, agents, or skills directory is ignored")
+			continue
+		}
+		if _, ok := requiredKeys[entry.Name()]; !ok {
+			report.add(path.Join(root, entryPath), 0, SeverityError, "structure", fmt.Sprintf("unknown type directory '%s' (expected commands, agents, skills, or profiles)", entry.Name()))
+			continue
+		}
+
+		files, err := fs.ReadDir(fsys, entryPath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", entryPath, err)
+		}
+		for _, file := range files {
+			filePath := path.Join(entryPath, file.Name())
+			switch {
+			case file.IsDir() && entry.Name() == "skills":
+				// Skill package: its SKILL.md is linted, the other files are supporting files
+				if _, err := fs.Stat(fsys, path.Join(filePath, embedpkg.SkillFile)); err != nil {
+					report.add(path.Join(root, filePath), 0, SeverityError, "structure", "skill directory has no "+embedpkg.SkillFile)
+					continue
+				}
+				filePath = path.Join(filePath, embedpkg.SkillFile)
+			case file.IsDir() || !strings.HasSuffix(file.Name(), ".md"):
+				report.add(path.Join(root, filePath), 0, SeverityWarning, "structure", "not a .md file; it is ignored")
+				continue
+			}
+
+			content, err := fs.ReadFile(fsys, filePath)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", filePath, err)
+			}
+			report.FilesChecked++
+
+			name := lintFile(fsys, root, filePath, entry.Name(), content, report)
+			lintDependencies(fsys, root, filePath, path.Dir(dir), path.Base(dir), content, report)
+			if name == "" || entry.Name() == embedpkg.ProfilesDir {
+				continue // profiles have their own namespace
+			}
+			if first, ok := names[name]; ok {
+				report.add(path.Join(root, filePath), keyLine(content, "name"), SeverityError, "unique-name",
+					fmt.Sprintf("name '%s' is already used by %s in this category", name, first))
+				continue
+			}
+			names[name] = path.Join(root, filePath)
+		}
+	}
+	return nil
+}
+
+// lintFile checks one catalog file and returns its frontmatter name
+func lintFile(fsys fs.FS, root, filePath, fileType string, content []byte, report *Report) string {
+	display := path.Join(root, filePath)
+
+	if len(content) > MaxFileSize {
+		report.add(display, 0, SeverityError, "size", fmt.Sprintf("file is %d KB, more than the %d KB limit", len(content)/1024, MaxFileSize/1024))
+	}
+
+	metadata, err := embedpkg.ParseMetadata(content)
+	if err == embedpkg.ErrNoFrontmatter {
+		report.add(display, 1, SeverityError, "frontmatter", "missing YAML frontmatter between --- lines")
+		return ""
+	}
+	if err != nil {
+		line := 1
+		if match := yamlLinePattern.FindStringSubmatch(err.Error()); match != nil {
+			n, _ := strconv.Atoi(match[1])
+			line = n + 1 // the frontmatter starts after the opening ---
+		} else if match := yamlFieldPattern.FindStringSubmatch(err.Error()); match != nil {
+			line = keyLine(content, match[1])
+		}
+		report.add(display, line, SeverityError, "frontmatter", err.Error())
+	} else {
+		// Only checked on valid frontmatter; a parse error already explains missing values
+		values := map[string]bool{
+			"name":        metadata.Name != "",
+			"description": metadata.Description != "",
+			"tools":       len(metadata.Tools) > 0,
+			"files":       len(metadata.Files) > 0,
+		}
+		for _, key := range requiredKeys[fileType] {
+			if !values[key] {
+				report.add(display, 1, SeverityError, "required", fmt.Sprintf("frontmatter is missing '%s'", key))
+			}
+		}
+	}
+
+	if fileType == "agents" {
+		for _, tool := range metadata.Tools {
+			// Tools may carry a permission pattern, as in Bash(git:*)
+			toolName, _, _ := strings.Cut(tool, "(")
+			if !KnownTools[toolName] && !strings.HasPrefix(toolName, "mcp__") {
+				report.add(display, keyLine(content, "tools"), SeverityWarning, "tools", fmt.Sprintf("unknown tool '%s'", tool))
+			}
+		}
+	}
+
+	stem := strings.TrimSuffix(path.Base(filePath), ".md")
+	if path.Base(filePath) == embedpkg.SkillFile {
+		stem = path.Base(path.Dir(filePath)) // skill packages are named by their directory
+	}
+	if metadata.Name != "" && metadata.Name != stem {
+		report.add(display, keyLine(content, "name"), SeverityWarning, "name-matches-file",
+			fmt.Sprintf("name '%s' does not match the filename '%s'", metadata.Name, stem))
+	}
+
+	if metadata.Template {
+		// Parse errors are reported at install time too, but only to whoever installs the file
+		if _, err := template.New(filePath).Parse(string(content)); err != nil {
+			report.add(display, templateLine(err), SeverityError, "template", err.Error())
+		}
+	}
+
+	lintLinks(fsys, root, filePath, content, report)
+	return metadata.Name
+}
+
+// lintDependencies checks the requires and recommends references of a catalog file, and the files of a profile
+// A malformed reference is an error; one that is not in the linted catalog is a warning,
+// since another catalog source may provide it
+func lintDependencies(fsys fs.FS, root, filePath, base, category string, content []byte, report *Report) {
+	metadata, _ := embedpkg.ParseMetadata(content)
+	display := path.Join(root, filePath)
+
+	check := func(key string, refs []string) {
+		for _, ref := range refs {
+			refCategory, fileType, filename, err := embedpkg.ParseReference(ref, category)
+			if err != nil {
+				report.add(display, keyLine(content, key), SeverityError, "dependency", fmt.Sprintf("%s: %v", key, err))
+				continue
+			}
+
+			flat := path.Join(base, refCategory, fileType, filename)
+			pkg := path.Join(base, refCategory, fileType, strings.TrimSuffix(filename, ".md"), embedpkg.SkillFile)
+			if _, err := fs.Stat(fsys, flat); err == nil {
+				continue
+			}
+			if _, err := fs.Stat(fsys, pkg); err == nil && fileType == "skills" {
+				continue
+			}
+			report.add(display, keyLine(content, key), SeverityWarning, "dependency",
+				fmt.Sprintf("%s '%s' is not in this catalog", key, embedpkg.Reference(refCategory, fileType, filename)))
+		}
+	}
+	check("requires", metadata.Requires)
+	check("recommends", metadata.Recommends)
+	check("files", metadata.Files)
+}
+
+// lintLinks reports relative markdown links whose targets do not exist in the catalog
+// Links inside fenced code blocks and inline code are examples and are not checked
+func lintLinks(fsys fs.FS, root, filePath string, content []byte, report *Report) {
+	inFence := false
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		for _, match := range linkPattern.FindAllStringSubmatch(stripInlineCode(line), -1) {
+			target := match[1]
+			if strings.Contains(target, ":") || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "/") {
+				continue // URLs, mailto:, anchors, and absolute paths
+			}
+			target, _, _ = strings.Cut(target, "#")
+			target, _, _ = strings.Cut(target, "?")
+			if target == "" {
+				continue
+			}
+
+			resolved := path.Join(path.Dir(filePath), target)
+			if _, err := fs.Stat(fsys, resolved); err != nil || strings.HasPrefix(resolved, "../") {
+				report.add(path.Join(root, filePath), i+1, SeverityError, "link", fmt.Sprintf("broken relative link '%s'", match[1]))
+			}
+		}
+	}
+}
+
+// templateLine returns the line of a text/template parse error, which reads template: NAME:LINE: ...
+func templateLine(err error) int {
+	if match := templateLinePattern.FindStringSubmatch(err.Error()); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		return n
+	}
+	return 1
+}
+
+// stripInlineCode removes `code spans` from a line
+func stripInlineCode(line string) string {
+	var sb strings.Builder
+	inCode := false
+	for _, r := range line {
+		if r == '`' {
+			inCode = !inCode
+			continue
+		}
+		if !inCode {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// keyLine returns the line of a frontmatter key, or 1 when it is not found
+func keyLine(content []byte, key string) int {
+	for i, line := range bytes.Split(content, []byte("\n")) {
+		if i > 0 && string(bytes.TrimSpace(line)) == "---" {
+			break
+		}
+		if bytes.HasPrefix(line, []byte(key+":")) {
+			return i + 1
+		}
+	}
+	return 1
+}
+
+func (r *Report) add(file string, line int, severity, rule, message string) {
+	r.Findings = append(r.Findings, Finding{File: file, Line: line, Severity: severity, Rule: rule, Message: message})
+	if severity == SeverityError {
+		r.Errors++
+	} else {
+		r.Warnings++
+	}
+}
+
+// WriteText writes one compiler-style annotation per finding: file:line: severity: message (rule)
+func WriteText(w io.Writer, report *Report) {
+	for _, f := range report.Findings {
+		location := f.File
+		if f.Line > 0 {
+			location += ":" + strconv.Itoa(f.Line)
+		}
+		fmt.Fprintf(w, "%s: %s: %s (%s)\n", location, f.Severity, f.Message, f.Rule)
+	}
+	fmt.Fprintf(w, "%d files checked: %d errors, %d warnings\n", report.FilesChecked, report.Errors, report.Warnings)
+}
+
+// WriteGitHub writes findings as GitHub Actions workflow commands, which show up as annotations
+func WriteGitHub(w io.Writer, report *Report) {
+	for _, f := range report.Findings {
+		properties := "file=" + escapeProperty(f.File)
+		if f.Line > 0 {
+			properties += ",line=" + strconv.Itoa(f.Line)
+		}
+		fmt.Fprintf(w, "::%s %s,title=%s::%s\n", f.Severity, properties, escapeProperty(f.Rule), escapeData(f.Message))
+	}
+}
+
+// escapeData escapes a workflow command message
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,87 @@
+package lint
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRun(t *testing.T) {
+	catalog := fstest.MapFS{
+		"categories/ops/agents/deployer.md":        {Data: []byte("---\nname: deployer\ndescription: Deploys\ntools: [Bash(git:*), Read, Teleport, mcp__github__create_issue]\n---\nSee [runbook](../skills/runbook.md) and [missing](notes.md#top).\n```\n[example](nowhere.md)\n```\nInline `[code](nowhere.md)` is fine.\n")},
+		"categories/ops/agents/untooled.md":        {Data: []byte("---\nname: untooled\ndescription: No tools\n---\n")},
+		"categories/ops/skills/runbook.md":         {Data: []byte("---\nname: deployer\ndescription: Duplicate name\n---\n")},
+		"categories/ops/commands/bare.md":          {Data: []byte("# No frontmatter\n")},
+		"categories/ops/commands/huge.md":          {Data: append([]byte("---\nname: huge\ndescription: Big\n---\n"), bytes.Repeat([]byte("x"), MaxFileSize)...)},
+		"categories/ops/command/typo.md":           {Data: []byte("---\nname: typo\ndescription: Wrong directory\n---\n")},
+		"categories/ops/skills/kit/SKILL.md":       {Data: []byte("---\nname: kit\ndescription: Package\n---\nRun [the script](scripts/run.sh).\n")},
+		"categories/ops/skills/kit/scripts/run.sh": {Data: []byte("#!/bin/sh\n")},
+		"categories/ops/skills/loose/run.sh":       {Data: []byte("#!/bin/sh\n")},
+		"categories/ops/profiles/oncall.md":        {Data: []byte("---\nname: oncall\ndescription: On call\nfiles: [agents/deployer, skills/gone]\n---\n")},
+		"categories/ops/commands/deploy.md":        {Data: []byte("---\nname: deploy\ndescription: Deploy\nrequires: [agents/deployer, skills/kit, skills/missing]\nrecommends: [runbook]\n---\n")},
+		"categories/ops/commands/release.md":       {Data: []byte("---\nname: release\ndescription: Release\ntemplate: true\n---\nTag {{.ProjectName}}\nPush {{.GitRepo | shout}}\n")},
+	}
+
+	report, err := Run(catalog, "")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []struct {
+		file, severity, rule, message string
+		line                          int
+	}{
+		{"categories/ops/agents/deployer.md", SeverityWarning, "tools", "unknown tool 'Teleport'", 4},
+		{"categories/ops/agents/deployer.md", SeverityError, "link", "broken relative link 'notes.md#top'", 6},
+		{"categories/ops/agents/untooled.md", SeverityError, "required", "frontmatter is missing 'tools'", 1},
+		{"categories/ops/skills/runbook.md", SeverityError, "unique-name", "name 'deployer' is already used by categories/ops/agents/deployer.md in this category", 2},
+		{"categories/ops/skills/runbook.md", SeverityWarning, "name-matches-file", "name 'deployer' does not match the filename 'runbook'", 2},
+		{"categories/ops/commands/bare.md", SeverityError, "frontmatter", "missing YAML frontmatter between --- lines", 1},
+		{"categories/ops/commands/huge.md", SeverityError, "size", "file is 64 KB, more than the 64 KB limit", 0},
+		{"categories/ops/command", SeverityError, "structure", "unknown type directory 'command' (expected commands, agents, skills, or profiles)", 0},
+		{"categories/ops/skills/loose", SeverityError, "structure", "skill directory has no SKILL.md", 0},
+		{"categories/ops/profiles/oncall.md", SeverityWarning, "dependency", "files 'ops/skills/gone' is not in this catalog", 4},
+		{"categories/ops/commands/deploy.md", SeverityWarning, "dependency", "requires 'ops/skills/missing' is not in this catalog", 4},
+		{"categories/ops/commands/deploy.md", SeverityError, "dependency", "recommends: invalid reference 'runbook' (expected <category>/<type>/<name> or <type>/<name>)", 5},
+		{"categories/ops/commands/release.md", SeverityError, "template", "template: categories/ops/commands/release.md:7: function \"shout\" not defined", 7},
+	}
+
+	for _, w := range want {
+		found := false
+		for _, f := range report.Findings {
+			if f.File == w.file && f.Severity == w.severity && f.Rule == w.rule && f.Message == w.message && f.Line == w.line {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing finding %s:%d: %s: %s (%s)", w.file, w.line, w.severity, w.message, w.rule)
+		}
+	}
+	if len(report.Findings) != len(want) {
+		var buf bytes.Buffer
+		WriteText(&buf, report)
+		t.Errorf("Run() found %d findings, want %d:\n%s", len(report.Findings), len(want), buf.String())
+	}
+	if report.Errors != 9 || report.Warnings != 4 || report.FilesChecked != 9 {
+		t.Errorf("Run() counted %d errors, %d warnings, %d files; want 9, 4, 9", report.Errors, report.Warnings, report.FilesChecked)
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	report := &Report{FilesChecked: 1}
+	report.add("categories/ops/agents/a.md", 3, SeverityError, "frontmatter", "bad: 100%")
+
+	var text bytes.Buffer
+	WriteText(&text, report)
+	if !strings.HasPrefix(text.String(), "categories/ops/agents/a.md:3: error: bad: 100% (frontmatter)\n") {
+		t.Errorf("WriteText() = %q", text.String())
+	}
+
+	var github bytes.Buffer
+	WriteGitHub(&github, report)
+	if github.String() != "::error file=categories/ops/agents/a.md,line=3,title=frontmatter::bad: 100%25\n" {
+		t.Errorf("WriteGitHub() = %q", github.String())
+	}
+}
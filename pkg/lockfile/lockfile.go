@@ -0,0 +1,169 @@
+package lockfile
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/shapestone/cc-foundry/pkg/state"
+)
+
+const (
+	LockFile = "cc-foundry.lock"
+	Version  = "1"
+)
+
+// Lock is the version-controlled record of what a project installs from the catalog
+type Lock struct {
+	Version string  `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry pins a single catalog file to the content it was installed with
+// Source is the configured catalog source the file came from, empty for the built-in catalog
+// Assets pins the supporting files of a skill package by slash path relative to its SKILL.md
+type Entry struct {
+	Source   string            `json:"source,omitempty"`
+	Category string            `json:"category"`
+	Type     string            `json:"type"`
+	File     string            `json:"file"`
+	SHA256   string            `json:"sha256"`
+	Assets   map[string]string `json:"assets,omitempty"`
+}
+
+// GetLockFilePath returns the lockfile path inside a project's .claude directory
+func GetLockFilePath(claudeDir string) string {
+	return filepath.Join(claudeDir, LockFile)
+}
+
+// Exists reports whether a lockfile exists inside a .claude directory
+func Exists(claudeDir string) bool {
+	_, err := os.Stat(GetLockFilePath(claudeDir))
+	return err == nil
+}
+
+// Load loads the lockfile from a .claude directory
+func Load(claudeDir string) (*Lock, error) {
+	lockFilePath := GetLockFilePath(claudeDir)
+
+	// If file doesn't exist, return empty lock
+	if _, err := os.Stat(lockFilePath); os.IsNotExist(err) {
+		return &Lock{
+			Version: Version,
+			Entries: []Entry{},
+		}, nil
+	}
+
+	data, err := os.ReadFile(lockFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile: %w", err)
+	}
+
+	return &lock, nil
+}
+
+// Save writes the lockfile to a .claude directory
+// Entries are sorted so the file diffs cleanly under version control
+func (l *Lock) Save(claudeDir string) error {
+	sort.Slice(l.Entries, func(i, j int) bool {
+		a, b := l.Entries[i], l.Entries[j]
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Source < b.Source
+	})
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lockfile: %w", err)
+	}
+	data = append(data, '\n')
+
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", claudeDir, err)
+	}
+
+	if err := state.WriteFileAtomic(GetLockFilePath(claudeDir), data, 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+
+	return nil
+}
+
+// Set adds or replaces the entry for a catalog file from a source and its supporting files
+func (l *Lock) Set(source, category, fileType, filename string, content []byte, assets map[string][]byte) {
+	l.Remove(source, category, fileType, filename)
+
+	entry := Entry{
+		Source:   source,
+		Category: category,
+		Type:     fileType,
+		File:     filename,
+		SHA256:   Hash(content),
+	}
+	for name, data := range assets {
+		if entry.Assets == nil {
+			entry.Assets = make(map[string]string)
+		}
+		entry.Assets[name] = Hash(data)
+	}
+	l.Entries = append(l.Entries, entry)
+}
+
+// Matches reports whether catalog content and its supporting files are exactly what the entry pins
+func (e *Entry) Matches(content []byte, assets map[string][]byte) bool {
+	if Hash(content) != e.SHA256 || len(assets) != len(e.Assets) {
+		return false
+	}
+	for name, data := range assets {
+		if e.Assets[name] != Hash(data) {
+			return false
+		}
+	}
+	return true
+}
+
+// Remove removes the entry for a catalog file from a source
+func (l *Lock) Remove(source, category, fileType, filename string) {
+	filtered := []Entry{}
+	for _, entry := range l.Entries {
+		if !entry.Is(source, category, fileType, filename) {
+			filtered = append(filtered, entry)
+		}
+	}
+	l.Entries = filtered
+}
+
+// Find finds the entry for a catalog file from a source
+func (l *Lock) Find(source, category, fileType, filename string) *Entry {
+	for _, entry := range l.Entries {
+		if entry.Is(source, category, fileType, filename) {
+			return &entry
+		}
+	}
+	return nil
+}
+
+// Is reports whether the entry pins a catalog file from a source
+func (e *Entry) Is(source, category, fileType, filename string) bool {
+	return e.Source == source && e.Category == category && e.Type == fileType && e.File == filename
+}
+
+// Hash calculates the SHA-256 hash recorded for content
+func Hash(content []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
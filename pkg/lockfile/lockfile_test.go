@@ -0,0 +1,86 @@
+package lockfile
+
+import (
+	"os"
+	"testing"
+)
+
+// TestLockRoundTrip tests that entries survive a save/load cycle in sorted order
+func TestLockRoundTrip(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "ccf-lock-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	lock, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() on missing lockfile error = %v", err)
+	}
+	if len(lock.Entries) != 0 {
+		t.Fatalf("Load() on missing lockfile returned %d entries, want 0", len(lock.Entries))
+	}
+
+	lock.Set("", "oss-development", "agents", "oss-auditor.md", []byte("agent"), nil)
+	lock.Set("", "development", "skills", "project-layout-go.md", []byte("v1"), nil)
+	lock.Set("", "development", "skills", "project-layout-go.md", []byte("v2"), nil) // replaces v1
+
+	if err := lock.Save(tmpDir); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(loaded.Entries) != 2 {
+		t.Fatalf("Load() returned %d entries, want 2", len(loaded.Entries))
+	}
+	if loaded.Entries[0].Category != "development" {
+		t.Errorf("Entries[0].Category = %q, want entries sorted by category", loaded.Entries[0].Category)
+	}
+	if loaded.Entries[0].SHA256 != Hash([]byte("v2")) {
+		t.Errorf("Entries[0].SHA256 = %q, want hash of replaced content", loaded.Entries[0].SHA256)
+	}
+
+	loaded.Remove("", "development", "skills", "project-layout-go.md")
+	if loaded.Find("", "development", "skills", "project-layout-go.md") != nil {
+		t.Error("Find() returned removed entry")
+	}
+	if loaded.Find("", "oss-development", "agents", "oss-auditor.md") == nil {
+		t.Error("Find() did not return remaining entry")
+	}
+}
+
+// TestLockSaveReplaces tests that saving over an existing lockfile replaces it without leaving temp files behind
+func TestLockSaveReplaces(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	lock := &Lock{}
+	lock.Set("", "development", "skills", "project-layout-go.md", []byte("v1"), nil)
+	if err := lock.Save(tmpDir); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	lock.Remove("", "development", "skills", "project-layout-go.md")
+	lock.Set("", "oss-development", "agents", "oss-auditor.md", []byte("agent"), nil)
+	if err := lock.Save(tmpDir); err != nil {
+		t.Fatalf("Save() over existing lockfile error = %v", err)
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != LockFile {
+		t.Errorf("Save() left %v in the directory, want only %s", entries, LockFile)
+	}
+
+	loaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded.Entries) != 1 || loaded.Find("", "oss-development", "agents", "oss-auditor.md") == nil {
+		t.Errorf("Load() = %+v, want only the replacing entry", loaded.Entries)
+	}
+}
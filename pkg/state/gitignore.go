@@ -0,0 +1,54 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// GitignoreFile keeps the state of a project's .claude/ directory out of version control
+// Only cc-foundry.lock is meant to be committed; the rest is per-checkout
+const GitignoreFile = ".gitignore"
+
+// gitignorePatterns cover the state file with its backup, lock and temp files, and the originals
+var gitignorePatterns = []string{
+	"/" + StateFile + "*",
+	"/" + OriginalsDir + "/",
+}
+
+// WriteGitignore adds the state files to the .gitignore of a .claude/ directory
+// Patterns the file already has are not repeated, and the rest of it is kept as it is
+func WriteGitignore(claudeDir string) error {
+	path := filepath.Join(claudeDir, GitignoreFile)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	lines := strings.Split(string(existing), "\n")
+	var missing []string
+	for _, pattern := range gitignorePatterns {
+		if !slices.Contains(lines, pattern) {
+			missing = append(missing, pattern)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	content := string(existing)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += "# cc-foundry state (commit cc-foundry.lock, not these)\n" + strings.Join(missing, "\n") + "\n"
+
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", claudeDir, err)
+	}
+	if err := WriteFileAtomic(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
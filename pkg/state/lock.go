@@ -0,0 +1,56 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LockSuffix is appended to the state file name to form its advisory lock file
+const LockSuffix = ".lock"
+
+// FileLock is an exclusive advisory lock on the state of one .claude/ directory
+type FileLock struct {
+	file *os.File
+}
+
+// Lock blocks until it holds the exclusive lock on a .claude/ directory's state
+// Hold it across the whole Load-modify-Save cycle so concurrent processes cannot
+// overwrite each other's records
+func Lock(claudeDir string) (*FileLock, error) {
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", claudeDir, err)
+	}
+
+	path := GetLockFilePath(claudeDir)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", path, err)
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	return &FileLock{file: f}, nil
+}
+
+// Unlock releases the lock
+func (l *FileLock) Unlock() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	err := unlockFile(l.file)
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file = nil
+	return err
+}
+
+// GetLockFilePath returns the path of the advisory lock file guarding a .claude/ directory's state
+func GetLockFilePath(claudeDir string) string {
+	return filepath.Join(claudeDir, StateFile+LockSuffix)
+}
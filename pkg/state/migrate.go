@@ -0,0 +1,111 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MigratedSuffix is appended to the legacy global state file once it has been split
+const MigratedSuffix = ".migrated"
+
+// GetLegacyStateFilePath returns the path of the pre-per-scope global state file (~/.cc-foundry.json)
+func GetLegacyStateFilePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return filepath.Join(home, StateFile), nil
+}
+
+// MigrateLegacy splits the global ~/.cc-foundry.json into per-.claude/ state files
+// Entries are grouped by the .claude/ directory that contains them; entries whose
+// directory no longer exists are dropped. The legacy file is kept with a .migrated suffix.
+// Returns the number of entries migrated (0 if there was nothing to migrate)
+func MigrateLegacy() (int, error) {
+	legacyPath, err := GetLegacyStateFilePath()
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := os.ReadFile(legacyPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read legacy state file: %w", err)
+	}
+
+	var legacy State
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return 0, fmt.Errorf("failed to parse legacy state file: %w", err)
+	}
+
+	// Group entries by the .claude/ directory that owns them
+	byRoot := make(map[string][]Installation)
+	for _, inst := range legacy.Installations {
+		if root := ClaudeRoot(inst.InstalledPath); root != "" {
+			byRoot[root] = append(byRoot[root], inst)
+		}
+	}
+
+	migrated := 0
+	for root, installations := range byRoot {
+		// Project was moved or deleted - nothing left to track
+		if _, err := os.Stat(root); err != nil {
+			continue
+		}
+
+		n, err := mergeInto(root, installations)
+		migrated += n
+		if err != nil {
+			return migrated, err
+		}
+	}
+
+	if err := os.Rename(legacyPath, legacyPath+MigratedSuffix); err != nil {
+		return migrated, fmt.Errorf("failed to rename legacy state file: %w", err)
+	}
+
+	return migrated, nil
+}
+
+// mergeInto adds legacy entries to the state of one .claude/ directory while holding its lock
+func mergeInto(root string, installations []Installation) (int, error) {
+	lock, err := Lock(root)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Unlock()
+
+	st, err := Load(root)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, inst := range installations {
+		if st.FindInstallation(inst.InstalledPath) == nil {
+			st.Installations = append(st.Installations, inst)
+		}
+	}
+
+	return len(installations), st.Save()
+}
+
+// ClaudeRoot returns the nearest enclosing .claude/ directory of a path, or "" if there is none
+func ClaudeRoot(path string) string {
+	dir := filepath.Dir(path)
+	for {
+		if filepath.Base(dir) == ".claude" {
+			return dir
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,77 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// OriginalsDir holds the content each file was installed from, named by its SHA-256
+// Installation.Hash and RenderedHash refer into it; the content written to disk is the base of three-way merges
+const OriginalsDir = ".cc-foundry-originals"
+
+// StoreOriginal keeps a copy of installed catalog content so later updates can merge against it
+func (s *State) StoreOriginal(content []byte) error {
+	if s.dir == "" {
+		return fmt.Errorf("state has no directory (use state.Load)")
+	}
+
+	dir := GetOriginalsDir(s.dir)
+	path := filepath.Join(dir, calculateHash(content))
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	if err := WriteFileAtomic(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to store original content: %w", err)
+	}
+	return nil
+}
+
+// LoadOriginal returns the content an installation was installed from
+// Returns an os.ErrNotExist error for installations made before originals were kept
+func (i *Installation) LoadOriginal(claudeDir string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(GetOriginalsDir(claudeDir), i.Hash))
+}
+
+// LoadInstalled returns the content written to disk when an installation was made:
+// the rendered content of a template, otherwise the original
+func (i *Installation) LoadInstalled(claudeDir string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(GetOriginalsDir(claudeDir), i.InstalledHash()))
+}
+
+// PruneOriginals removes stored content no installation refers to any more
+func (s *State) PruneOriginals() error {
+	dir := GetOriginalsDir(s.dir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	referenced := make(map[string]bool)
+	for _, inst := range s.Installations {
+		referenced[inst.Hash] = true
+		referenced[inst.RenderedHash] = true
+	}
+
+	for _, entry := range entries {
+		if referenced[entry.Name()] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to prune original %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// GetOriginalsDir returns the directory holding installed catalog content for a .claude/ directory
+func GetOriginalsDir(claudeDir string) string {
+	return filepath.Join(claudeDir, OriginalsDir)
+}
@@ -6,42 +6,61 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 )
 
 const (
 	StateFile = ".cc-foundry.json"
 	Version   = "2.0.0"
+
+	// BackupSuffix is appended to the state file name for the copy of the previous state
+	BackupSuffix = ".bak"
 )
 
-// State represents the foundry installation state
+// State represents the foundry installation state of one .claude/ directory
 type State struct {
 	Version       string         `json:"version"`
 	Installations []Installation `json:"installations"`
+
+	dir string // .claude/ directory this state belongs to
 }
 
 // Installation represents a single installed file
+// InstalledPath is absolute in memory and stored relative to the .claude/ directory on disk
+// Hash identifies the catalog content the file was installed from (see OriginalsDir)
+// RenderedHash identifies the content written to disk when a catalog template was rendered; it is empty otherwise
+// Version is the frontmatter version of that content, empty when it has none
+// Assets hashes the supporting files of a skill package by slash path relative to InstalledPath's directory
+// Profiles names the profiles that brought the file in; it is empty for files installed directly
+// Source is the configured catalog source the file came from, empty for the built-in catalog
+// NamePrefix is the start of installed names under the naming policy it was installed with; empty means ccf-
 type Installation struct {
-	Category      string    `json:"category"`
-	Type          string    `json:"type"`
-	File          string    `json:"file"`
-	InstalledPath string    `json:"installed_path"`
-	Hash          string    `json:"hash"`
-	InstalledAt   time.Time `json:"installed_at"`
+	Category      string            `json:"category"`
+	Type          string            `json:"type"`
+	File          string            `json:"file"`
+	InstalledPath string            `json:"installed_path"`
+	Hash          string            `json:"hash"`
+	RenderedHash  string            `json:"rendered_hash,omitempty"`
+	Version       string            `json:"version,omitempty"`
+	Assets        map[string]string `json:"assets,omitempty"`
+	Profiles      []string          `json:"profiles,omitempty"`
+	Source        string            `json:"source,omitempty"`
+	NamePrefix    string            `json:"name_prefix,omitempty"`
+	InstalledAt   time.Time         `json:"installed_at"`
 }
 
-// Load loads the state file from the user's home directory
-func Load() (*State, error) {
-	stateFilePath, err := GetStateFilePath()
-	if err != nil {
-		return nil, err
-	}
+// Load loads the state file owned by a .claude/ directory
+func Load(claudeDir string) (*State, error) {
+	stateFilePath := GetStateFilePath(claudeDir)
 
 	// If file doesn't exist, return empty state
 	if _, err := os.Stat(stateFilePath); os.IsNotExist(err) {
 		return &State{
 			Version:       Version,
 			Installations: []Installation{},
+			dir:           claudeDir,
 		}, nil
 	}
 
@@ -52,33 +71,116 @@ func Load() (*State, error) {
 
 	var state State
 	if err := json.Unmarshal(data, &state); err != nil {
+		if _, statErr := os.Stat(GetBackupFilePath(claudeDir)); statErr == nil {
+			return nil, fmt.Errorf("failed to parse state file (previous state kept at %s): %w", GetBackupFilePath(claudeDir), err)
+		}
 		return nil, fmt.Errorf("failed to parse state file: %w", err)
 	}
 
+	// Resolve relative paths against the directory the state lives in,
+	// so moving or renaming a project keeps its entries valid
+	for i := range state.Installations {
+		if !filepath.IsAbs(state.Installations[i].InstalledPath) {
+			state.Installations[i].InstalledPath = filepath.Join(claudeDir, filepath.FromSlash(state.Installations[i].InstalledPath))
+		}
+	}
+	state.dir = claudeDir
+
 	return &state, nil
 }
 
-// Save saves the state file to the user's home directory
+// Save saves the state file into its .claude/ directory
+// The new state is written to a temp file, fsync'd and renamed into place,
+// so a crash mid-write never leaves a truncated file. The previous state is kept as .bak
 func (s *State) Save() error {
-	stateFilePath, err := GetStateFilePath()
-	if err != nil {
-		return err
+	if s.dir == "" {
+		return fmt.Errorf("state has no directory (use state.Load)")
+	}
+
+	// Store paths relative to the .claude/ directory
+	out := *s
+	out.Installations = make([]Installation, len(s.Installations))
+	for i, inst := range s.Installations {
+		if rel, err := filepath.Rel(s.dir, inst.InstalledPath); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			inst.InstalledPath = filepath.ToSlash(rel)
+		}
+		out.Installations[i] = inst
 	}
 
-	data, err := json.MarshalIndent(s, "", "  ")
+	data, err := json.MarshalIndent(out, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
 
-	if err := os.WriteFile(stateFilePath, data, 0644); err != nil {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", s.dir, err)
+	}
+
+	stateFilePath := GetStateFilePath(s.dir)
+
+	// Keep the previous state for recovery
+	if previous, err := os.ReadFile(stateFilePath); err == nil {
+		if err := WriteFileAtomic(GetBackupFilePath(s.dir), previous, 0644); err != nil {
+			return fmt.Errorf("failed to back up state file: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	if err := WriteFileAtomic(stateFilePath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write state file: %w", err)
 	}
 
 	return nil
 }
 
-// AddInstallation adds a new installation to the state
-func (s *State) AddInstallation(category, fileType, filename, installedPath string, content []byte) {
+// WriteFileAtomic writes data to a temp file next to path, fsyncs it and renames it over path
+// A crash mid-write leaves the previous file in place instead of a truncated one
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	// Clean up the temp file unless it was renamed into place
+	renamed := false
+	defer func() {
+		if !renamed {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	renamed = true
+
+	return nil
+}
+
+// Dir returns the .claude/ directory this state belongs to
+func (s *State) Dir() string {
+	return s.dir
+}
+
+// AddInstallation adds a new installation of content at a frontmatter version to the state
+func (s *State) AddInstallation(category, fileType, filename, installedPath string, content []byte, version string) {
 	hash := calculateHash(content)
 
 	installation := Installation{
@@ -87,12 +189,87 @@ func (s *State) AddInstallation(category, fileType, filename, installedPath stri
 		File:          filename,
 		InstalledPath: installedPath,
 		Hash:          hash,
+		Version:       version,
 		InstalledAt:   time.Now(),
 	}
 
 	s.Installations = append(s.Installations, installation)
 }
 
+// SetAssets records the supporting files installed next to an installation
+func (s *State) SetAssets(installedPath string, assets map[string][]byte) {
+	for i := range s.Installations {
+		if s.Installations[i].InstalledPath == installedPath {
+			s.Installations[i].Assets = hashAssets(assets)
+		}
+	}
+}
+
+// SetRendered records the content a templated installation was rendered to
+// Content equal to the catalog content clears it
+func (s *State) SetRendered(installedPath string, rendered []byte) {
+	for i := range s.Installations {
+		inst := &s.Installations[i]
+		if inst.InstalledPath == installedPath {
+			inst.RenderedHash = ""
+			if hash := calculateHash(rendered); hash != inst.Hash {
+				inst.RenderedHash = hash
+			}
+		}
+	}
+}
+
+// SetNaming records the catalog source of an installation and the name prefix it was installed under
+func (s *State) SetNaming(installedPath, source, namePrefix string) {
+	for i := range s.Installations {
+		if s.Installations[i].InstalledPath == installedPath {
+			s.Installations[i].Source = source
+			s.Installations[i].NamePrefix = namePrefix
+		}
+	}
+}
+
+// AddProfile records that a profile brought an installation in
+func (s *State) AddProfile(installedPath, profile string) {
+	for i := range s.Installations {
+		inst := &s.Installations[i]
+		if inst.InstalledPath == installedPath && !slices.Contains(inst.Profiles, profile) {
+			inst.Profiles = append(inst.Profiles, profile)
+		}
+	}
+}
+
+// RemoveProfile forgets that a profile brought an installation in
+func (s *State) RemoveProfile(installedPath, profile string) {
+	for i := range s.Installations {
+		inst := &s.Installations[i]
+		if inst.InstalledPath == installedPath {
+			// Copied so earlier copies of the installation keep their list
+			inst.Profiles = slices.DeleteFunc(slices.Clone(inst.Profiles), func(p string) bool { return p == profile })
+		}
+	}
+}
+
+// ClearProfiles forgets every profile that brought an installation in
+func (s *State) ClearProfiles(installedPath string) {
+	for i := range s.Installations {
+		if s.Installations[i].InstalledPath == installedPath {
+			s.Installations[i].Profiles = nil
+		}
+	}
+}
+
+// ListProfileInstallations returns the installations a profile brought in
+func (s *State) ListProfileInstallations(profile string) []Installation {
+	var filtered []Installation
+	for _, inst := range s.Installations {
+		if slices.Contains(inst.Profiles, profile) {
+			filtered = append(filtered, inst)
+		}
+	}
+	return filtered
+}
+
 // RemoveInstallation removes an installation from the state
 func (s *State) RemoveInstallation(installedPath string) {
 	var filtered []Installation
@@ -114,6 +291,16 @@ func (s *State) FindInstallation(installedPath string) *Installation {
 	return nil
 }
 
+// FindFile finds the installation of a catalog file from a source, wherever the naming policy of its install put it
+func (s *State) FindFile(source, category, fileType, filename string) *Installation {
+	for _, inst := range s.Installations {
+		if inst.Source == source && inst.Category == category && inst.Type == fileType && inst.File == filename {
+			return &inst
+		}
+	}
+	return nil
+}
+
 // ListInstallations returns all installations, optionally filtered by category and/or type
 func (s *State) ListInstallations(category, fileType string) []Installation {
 	var filtered []Installation
@@ -131,14 +318,14 @@ func (s *State) ListInstallations(category, fileType string) []Installation {
 	return filtered
 }
 
-// GetStateFilePath returns the full path to the state file
-func GetStateFilePath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
+// GetStateFilePath returns the full path to the state file owned by a .claude/ directory
+func GetStateFilePath(claudeDir string) string {
+	return filepath.Join(claudeDir, StateFile)
+}
 
-	return filepath.Join(home, StateFile), nil
+// GetBackupFilePath returns the path of the copy of the previous state kept by Save
+func GetBackupFilePath(claudeDir string) string {
+	return filepath.Join(claudeDir, StateFile+BackupSuffix)
 }
 
 // calculateHash calculates SHA-256 hash of content
@@ -147,8 +334,56 @@ func calculateHash(content []byte) string {
 	return fmt.Sprintf("%x", hash)
 }
 
-// HasContentChanged checks if file content has changed from what was installed
+// InstalledHash returns the hash of the content written to disk at install: the rendered content of a template, otherwise Hash
+func (i *Installation) InstalledHash() string {
+	if i.RenderedHash != "" {
+		return i.RenderedHash
+	}
+	return i.Hash
+}
+
+// IsModified reports whether content on disk differs from what was written at install
+func (i *Installation) IsModified(content []byte) bool {
+	return calculateHash(content) != i.InstalledHash()
+}
+
+// HasContentChanged checks if catalog content has changed from what was installed
 func (i *Installation) HasContentChanged(newContent []byte) bool {
 	newHash := calculateHash(newContent)
 	return newHash != i.Hash
 }
+
+// HasAssetsChanged checks if the supporting files differ from what was installed
+func (i *Installation) HasAssetsChanged(assets map[string][]byte) bool {
+	if len(assets) != len(i.Assets) {
+		return true
+	}
+	for name, content := range assets {
+		if i.Assets[name] != calculateHash(content) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAssetChanged checks if a supporting file's content differs from what was installed
+func (i *Installation) HasAssetChanged(name string, content []byte) bool {
+	return calculateHash(content) != i.Assets[name]
+}
+
+// AssetPath returns where a supporting file of the installation is installed
+func (i *Installation) AssetPath(name string) string {
+	return filepath.Join(filepath.Dir(i.InstalledPath), filepath.FromSlash(name))
+}
+
+// hashAssets maps each supporting file to its SHA-256 hash, or returns nil when there are none
+func hashAssets(assets map[string][]byte) map[string]string {
+	if len(assets) == 0 {
+		return nil
+	}
+	hashes := make(map[string]string, len(assets))
+	for name, content := range assets {
+		hashes[name] = calculateHash(content)
+	}
+	return hashes
+}
@@ -0,0 +1,267 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveStoresRelativePaths(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+
+	st, err := Load(claudeDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	installedPath := filepath.Join(claudeDir, "commands", "ccf-development-test.md")
+	st.AddInstallation("development", "commands", "test.md", installedPath, []byte("content"), "")
+	// A name starting with .. is still inside the directory, a sibling of it is not
+	dotted := filepath.Join(claudeDir, "..notes", "a.md")
+	outside := filepath.Join(filepath.Dir(claudeDir), "notes", "b.md")
+	st.AddInstallation("development", "commands", "a.md", dotted, []byte("a"), "")
+	st.AddInstallation("development", "commands", "b.md", outside, []byte("b"), "")
+	if err := st.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(GetStateFilePath(claudeDir))
+	if err != nil {
+		t.Fatalf("failed to read state file: %v", err)
+	}
+	for _, want := range []string{`"installed_path": "commands/ccf-development-test.md"`, `"installed_path": "..notes/a.md"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("state file should store a relative path %s, got:\n%s", want, data)
+		}
+	}
+	if !strings.Contains(string(data), `"installed_path": `+strconv.Quote(outside)) {
+		t.Errorf("state file should store a path outside the directory as absolute, got:\n%s", data)
+	}
+	st.RemoveInstallation(dotted)
+	st.RemoveInstallation(outside)
+	if err := st.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Loading from a moved directory resolves paths against the new location
+	movedDir := filepath.Join(t.TempDir(), ".claude")
+	if err := os.Rename(claudeDir, movedDir); err != nil {
+		t.Fatalf("failed to move directory: %v", err)
+	}
+	loaded, err := Load(movedDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := filepath.Join(movedDir, "commands", "ccf-development-test.md")
+	if len(loaded.Installations) != 1 || loaded.Installations[0].InstalledPath != want {
+		t.Errorf("Load() installations = %+v, want path %s", loaded.Installations, want)
+	}
+}
+
+func TestMigrateLegacy(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	userDir := filepath.Join(home, ".claude")
+	projectDir := filepath.Join(home, "work", "app", ".claude")
+	goneDir := filepath.Join(home, "deleted", ".claude")
+	for _, dir := range []string{userDir, projectDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	legacy := State{
+		Version: Version,
+		Installations: []Installation{
+			{Category: "development", Type: "commands", File: "a.md", InstalledPath: filepath.Join(userDir, "commands", "ccf-development-a.md")},
+			{Category: "development", Type: "agents", File: "b.md", InstalledPath: filepath.Join(projectDir, "agents", "ccf-development-b.md")},
+			{Category: "development", Type: "commands", File: "c.md", InstalledPath: filepath.Join(goneDir, "commands", "ccf-development-c.md")},
+		},
+	}
+	data, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyPath := filepath.Join(home, StateFile)
+	if err := os.WriteFile(legacyPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := MigrateLegacy()
+	if err != nil {
+		t.Fatalf("MigrateLegacy() error = %v", err)
+	}
+	if migrated != 2 {
+		t.Errorf("MigrateLegacy() = %d, want 2", migrated)
+	}
+
+	for _, dir := range []string{userDir, projectDir} {
+		st, err := Load(dir)
+		if err != nil {
+			t.Fatalf("Load(%s) error = %v", dir, err)
+		}
+		if len(st.Installations) != 1 {
+			t.Errorf("%s has %d installations, want 1", dir, len(st.Installations))
+		}
+	}
+
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Errorf("legacy state file should have been renamed")
+	}
+	if _, err := os.Stat(legacyPath + MigratedSuffix); err != nil {
+		t.Errorf("expected %s%s: %v", legacyPath, MigratedSuffix, err)
+	}
+
+	// A second run is a no-op
+	if migrated, err := MigrateLegacy(); err != nil || migrated != 0 {
+		t.Errorf("second MigrateLegacy() = %d, %v; want 0, nil", migrated, err)
+	}
+}
+
+func TestSaveKeepsBackup(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+
+	st, err := Load(claudeDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	st.AddInstallation("development", "commands", "a.md", filepath.Join(claudeDir, "commands", "ccf-development-a.md"), []byte("a"), "")
+	if err := st.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	first, err := os.ReadFile(GetStateFilePath(claudeDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st.AddInstallation("development", "commands", "b.md", filepath.Join(claudeDir, "commands", "ccf-development-b.md"), []byte("b"), "")
+	if err := st.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	backup, err := os.ReadFile(GetBackupFilePath(claudeDir))
+	if err != nil {
+		t.Fatalf("expected a backup of the previous state: %v", err)
+	}
+	if string(backup) != string(first) {
+		t.Errorf("backup = %s, want previous state %s", backup, first)
+	}
+
+	// No temp files are left behind
+	entries, err := os.ReadDir(claudeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("leftover temp file %s", entry.Name())
+		}
+	}
+}
+
+func TestLockIsExclusive(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+
+	lock, err := Lock(claudeDir)
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	acquired := make(chan *FileLock)
+	go func() {
+		second, err := Lock(claudeDir)
+		if err != nil {
+			t.Errorf("second Lock() error = %v", err)
+		}
+		acquired <- second
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second Lock() returned while the first lock was held")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	select {
+	case second := <-acquired:
+		second.Unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("second Lock() did not return after Unlock()")
+	}
+}
+
+func TestOriginals(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+
+	st, err := Load(claudeDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, content := range []string{"kept", "stale"} {
+		if err := st.StoreOriginal([]byte(content)); err != nil {
+			t.Fatalf("StoreOriginal() error = %v", err)
+		}
+	}
+	st.AddInstallation("development", "commands", "a.md", filepath.Join(claudeDir, "commands", "ccf-development-a.md"), []byte("kept"), "")
+
+	if err := st.PruneOriginals(); err != nil {
+		t.Fatalf("PruneOriginals() error = %v", err)
+	}
+
+	original, err := st.Installations[0].LoadOriginal(claudeDir)
+	if err != nil || string(original) != "kept" {
+		t.Errorf("LoadOriginal() = %q, %v; want %q", original, err, "kept")
+	}
+	entries, err := os.ReadDir(GetOriginalsDir(claudeDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("originals directory has %d entries after pruning, want 1", len(entries))
+	}
+}
+
+// TestWriteGitignore tests that the state patterns are added once and the rest of the file is kept
+func TestWriteGitignore(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	path := filepath.Join(claudeDir, GitignoreFile)
+
+	if err := WriteGitignore(claudeDir); err != nil {
+		t.Fatalf("WriteGitignore() error = %v", err)
+	}
+	created, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("WriteGitignore() did not create %s: %v", path, err)
+	}
+	for _, want := range []string{"/.cc-foundry.json*\n", "/.cc-foundry-originals/\n"} {
+		if !strings.Contains(string(created), want) {
+			t.Errorf("%s = %q, want it to contain %q", GitignoreFile, created, want)
+		}
+	}
+	if err := WriteGitignore(claudeDir); err != nil {
+		t.Fatalf("second WriteGitignore() error = %v", err)
+	}
+	if again, _ := os.ReadFile(path); string(again) != string(created) {
+		t.Errorf("second WriteGitignore() changed the file to %q", again)
+	}
+
+	// An existing file keeps its own lines and only gets the patterns it lacks
+	if err := os.WriteFile(path, []byte("settings.local.json\n/.cc-foundry-originals/"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteGitignore(claudeDir); err != nil {
+		t.Fatalf("WriteGitignore() error = %v", err)
+	}
+	updated, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(updated), "settings.local.json\n/.cc-foundry-originals/\n") || strings.Count(string(updated), "/.cc-foundry-originals/") != 1 || !strings.Contains(string(updated), "/.cc-foundry.json*\n") {
+		t.Errorf("WriteGitignore() on an existing file wrote %q", updated)
+	}
+}
@@ -3,8 +3,12 @@ package main
 import (
 	"archive/tar"
 	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"os"
@@ -12,54 +16,29 @@ import (
 	"strings"
 	"time"
 
-	"github.com/shapestone/shape-yaml/pkg/yaml"
+	embedpkg "github.com/shapestone/cc-foundry/pkg/embed"
 )
 
-// Frontmatter represents the YAML frontmatter in markdown files
-type Frontmatter struct {
-	Name        string   `yaml:"name"`
-	Description string   `yaml:"description"`
-	Tools       []string `yaml:"tools,omitempty"`
-}
-
-// FileEntry represents a single file in the manifest
-type FileEntry struct {
-	Name        string `json:"name"`
-	File        string `json:"file"`
-	Description string `json:"description"`
-	SHA256      string `json:"sha256"`
-}
-
-// CategoryFiles represents files grouped by type (commands, agents, skills)
-type CategoryFiles struct {
-	Commands []FileEntry `json:"commands,omitempty"`
-	Agents   []FileEntry `json:"agents,omitempty"`
-	Skills   []FileEntry `json:"skills,omitempty"`
-}
-
-// Manifest represents the complete manifest structure
-type Manifest struct {
-	Version    string                   `json:"version"`
-	Generated  string                   `json:"generated"`
-	Categories map[string]CategoryFiles `json:"categories"`
-	Bundle     BundleInfo               `json:"bundle"`
-}
-
-// BundleInfo contains information about the bundle archive
-type BundleInfo struct {
-	File   string `json:"file"`
-	SHA256 string `json:"sha256"`
-	Size   int64  `json:"size"`
-}
-
 func main() {
-	if err := run(); err != nil {
+	signKey := flag.String("sign-key", os.Getenv("CC_FOUNDRY_SIGNING_KEY"), "ed25519 private key file used to sign manifest.json and bundle.tar.gz")
+	genKey := flag.String("genkey", "", "write a new key pair to <name>.key and <name>.pub and exit")
+	flag.Parse()
+
+	if *genKey != "" {
+		if err := generateKey(*genKey); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := run(*signKey); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func run() error {
+func run(signKey string) error {
 	filesDir := "files"
 	manifestPath := filepath.Join(filesDir, "manifest.json")
 	bundlePath := filepath.Join(filesDir, "bundle.tar.gz")
@@ -72,10 +51,10 @@ func run() error {
 	}
 
 	// Build manifest by scanning files
-	manifest := &Manifest{
+	manifest := &embedpkg.Manifest{
 		Version:    "1.0",
 		Generated:  time.Now().UTC().Format(time.RFC3339),
-		Categories: make(map[string]CategoryFiles),
+		Categories: make(map[string]embedpkg.CategoryFiles),
 	}
 
 	// Walk through files directory
@@ -95,14 +74,19 @@ func run() error {
 		}
 
 		// Parse the path to extract category and type
-		// Expected format: files/category/type/filename.md
+		// Expected format: files/category/type/filename.md, or files/category/skills/name/SKILL.md
+		// for a skill package whose other files are its supporting files
 		relPath, err := filepath.Rel(filesDir, path)
 		if err != nil {
 			return err
 		}
 
 		parts := strings.Split(filepath.ToSlash(relPath), "/")
-		if len(parts) < 3 {
+		isPackage := len(parts) == 4 && parts[1] == "skills" && parts[3] == embedpkg.SkillFile
+		if len(parts) > 3 && parts[1] == "skills" && !isPackage {
+			return nil // supporting file of a skill package, listed with its SKILL.md
+		}
+		if len(parts) != 3 && !isPackage {
 			fmt.Printf("⚠️  Skipping %s (unexpected path structure)\n", relPath)
 			return nil
 		}
@@ -111,7 +95,7 @@ func run() error {
 		fileType := parts[1]
 
 		// Validate type
-		if fileType != "commands" && fileType != "agents" && fileType != "skills" {
+		if fileType != "commands" && fileType != "agents" && fileType != "skills" && fileType != embedpkg.ProfilesDir {
 			fmt.Printf("⚠️  Skipping %s (unknown type: %s)\n", relPath, fileType)
 			return nil
 		}
@@ -126,27 +110,33 @@ func run() error {
 		hash := fmt.Sprintf("%x", sha256.Sum256(content))
 
 		// Parse frontmatter
-		frontmatter, err := parseFrontmatter(content)
+		frontmatter, err := embedpkg.ParseMetadata(content)
 		if err != nil {
 			fmt.Printf("⚠️  Warning: failed to parse frontmatter in %s: %v\n", relPath, err)
 			// Continue anyway with default values
-			frontmatter = &Frontmatter{
-				Name:        strings.TrimSuffix(filepath.Base(path), ".md"),
+			frontmatter = embedpkg.Metadata{
+				Name:        strings.TrimSuffix(parts[2], ".md"),
 				Description: "No description available",
 			}
 		}
 
 		// Create file entry
-		entry := FileEntry{
+		entry := embedpkg.FileEntry{
 			Name:        frontmatter.Name,
-			File:        relPath,
+			File:        filepath.ToSlash(relPath),
 			Description: frontmatter.Description,
 			SHA256:      hash,
 		}
+		if isPackage {
+			entry.Assets, err = packageAssets(filesDir, filepath.Dir(path))
+			if err != nil {
+				return err
+			}
+		}
 
 		// Add to manifest
 		if _, exists := manifest.Categories[category]; !exists {
-			manifest.Categories[category] = CategoryFiles{}
+			manifest.Categories[category] = embedpkg.CategoryFiles{}
 		}
 
 		catFiles := manifest.Categories[category]
@@ -157,6 +147,8 @@ func run() error {
 			catFiles.Agents = append(catFiles.Agents, entry)
 		case "skills":
 			catFiles.Skills = append(catFiles.Skills, entry)
+		case embedpkg.ProfilesDir:
+			catFiles.Profiles = append(catFiles.Profiles, entry)
 		}
 		manifest.Categories[category] = catFiles
 
@@ -187,56 +179,37 @@ func run() error {
 		return fmt.Errorf("failed to write manifest: %w", err)
 	}
 
+	// Sign manifest and bundle
+	if signKey != "" {
+		fmt.Println("\n🔏 Signing manifest and bundle...")
+		if err := signFiles(signKey, manifestPath, bundlePath); err != nil {
+			return fmt.Errorf("failed to sign: %w", err)
+		}
+	} else {
+		fmt.Println("\n⚠️  No signing key given (-sign-key or CC_FOUNDRY_SIGNING_KEY); the catalog is unsigned")
+	}
+
 	// Print summary
 	fmt.Println("\n✓ Manifest generation complete!")
 	fmt.Printf("  Categories: %d\n", len(manifest.Categories))
 
-	totalFiles := 0
+	totalFiles, totalProfiles := 0, 0
 	for _, cat := range manifest.Categories {
 		totalFiles += len(cat.Commands) + len(cat.Agents) + len(cat.Skills)
+		totalProfiles += len(cat.Profiles)
 	}
 	fmt.Printf("  Total files: %d\n", totalFiles)
+	if totalProfiles > 0 {
+		fmt.Printf("  Profiles: %d\n", totalProfiles)
+	}
 	fmt.Printf("  Bundle size: %.2f KB\n", float64(bundleInfo.Size)/1024)
 	fmt.Printf("  Output: %s\n", manifestPath)
 
 	return nil
 }
 
-// parseFrontmatter extracts and parses YAML frontmatter from markdown content
-func parseFrontmatter(content []byte) (*Frontmatter, error) {
-	// Frontmatter is between --- delimiters at the start of the file
-	lines := strings.Split(string(content), "\n")
-	if len(lines) < 3 || strings.TrimSpace(lines[0]) != "---" {
-		return nil, fmt.Errorf("no frontmatter found")
-	}
-
-	// Find the closing ---
-	endIndex := -1
-	for i := 1; i < len(lines); i++ {
-		if strings.TrimSpace(lines[i]) == "---" {
-			endIndex = i
-			break
-		}
-	}
-
-	if endIndex == -1 {
-		return nil, fmt.Errorf("frontmatter not closed")
-	}
-
-	// Extract frontmatter content
-	yamlContent := strings.Join(lines[1:endIndex], "\n")
-
-	// Parse YAML
-	var frontmatter Frontmatter
-	if err := yaml.Unmarshal([]byte(yamlContent), &frontmatter); err != nil {
-		return nil, fmt.Errorf("invalid YAML: %w", err)
-	}
-
-	return &frontmatter, nil
-}
-
 // createBundle creates a tar.gz archive of all files
-func createBundle(filesDir, bundlePath string) (*BundleInfo, error) {
+func createBundle(filesDir, bundlePath string) (*embedpkg.BundleInfo, error) {
 	// Create bundle file
 	bundleFile, err := os.Create(bundlePath)
 	if err != nil {
@@ -263,8 +236,7 @@ func createBundle(filesDir, bundlePath string) (*BundleInfo, error) {
 			return nil
 		}
 
-		// Skip directories and non-.md files
-		if info.IsDir() || !strings.HasSuffix(path, ".md") {
+		if info.IsDir() {
 			return nil
 		}
 
@@ -274,6 +246,13 @@ func createBundle(filesDir, bundlePath string) (*BundleInfo, error) {
 			return err
 		}
 
+		// Bundle .md files and everything inside skill packages
+		parts := strings.Split(filepath.ToSlash(relPath), "/")
+		inPackage := len(parts) > 3 && parts[1] == "skills"
+		if !strings.HasSuffix(path, ".md") && !inPackage {
+			return nil
+		}
+
 		// Create tar header
 		header := &tar.Header{
 			Name:    filepath.ToSlash(relPath),
@@ -323,9 +302,82 @@ func createBundle(filesDir, bundlePath string) (*BundleInfo, error) {
 		return nil, err
 	}
 
-	return &BundleInfo{
+	return &embedpkg.BundleInfo{
 		File:   "bundle.tar.gz",
 		SHA256: hash,
 		Size:   bundleInfo.Size(),
 	}, nil
 }
+
+// packageAssets lists the supporting files of the skill package in dir: every file except its SKILL.md
+func packageAssets(filesDir, dir string) ([]embedpkg.AssetEntry, error) {
+	var assets []embedpkg.AssetEntry
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || path == filepath.Join(dir, embedpkg.SkillFile) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		relPath, err := filepath.Rel(filesDir, path)
+		if err != nil {
+			return err
+		}
+
+		assets = append(assets, embedpkg.AssetEntry{
+			File:   filepath.ToSlash(relPath),
+			SHA256: fmt.Sprintf("%x", sha256.Sum256(content)),
+		})
+		return nil
+	})
+	return assets, err
+}
+
+// generateKey writes a new ed25519 key pair: <name>.key (private, base64) and <name>.pub (public, base64)
+func generateKey(name string) error {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	if err := os.WriteFile(name+".key", []byte(base64.StdEncoding.EncodeToString(privateKey)+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(publicKey)
+	if err := os.WriteFile(name+".pub", []byte(encoded+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
+	}
+
+	fmt.Printf("✓ Wrote %s.key (keep it secret) and %s.pub\n", name, name)
+	fmt.Printf("  Trust it with: \"public_keys\": [\"%s\"]\n", encoded)
+	return nil
+}
+
+// signFiles writes a detached <file>.sig for every file using the private key in keyPath
+func signFiles(keyPath string, files ...string) error {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read signing key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		return fmt.Errorf("%s is not a base64 ed25519 private key", keyPath)
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file+embedpkg.SignatureSuffix, embedpkg.Sign(ed25519.PrivateKey(key), content), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Signed %s\n", file)
+	}
+	return nil
+}